@@ -0,0 +1,442 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/geotiff"
+	"flightplan2litchimission/lenconv"
+	"flightplan2litchimission/missioncsv"
+	"flightplan2litchimission/missionview"
+)
+
+func main() {
+	// Dispatch subcommands before parsing the conversion flags
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:]))
+	}
+
+	interval := lenconv.PhotoIntervalFlag("d", 0, "interval between projection centres in meters or feet, e.g. 20m or 60ft")
+	altitudeMode := flag.String("altitude-mode", "agl", "source of altitude data: 'asl' (absolute) or 'agl' (above ground level)")
+	pitch := flag.Float64("pitch", -90, "gimbal pitch angle in degrees (-90 to 0)")
+	poi := flag.String("poi", "", "point of interest as latitude,longitude[,altitude] to aim the gimbal and headings at")
+	headingMode := flag.String("heading", "", "heading mode: 'course', 'fixed', 'offset', 'poi' or 'smooth' (default 'poi' with -poi and 'course' otherwise)")
+	headingAzimuth := flag.Float64("heading-azimuth", 0, "heading in degrees from north for the 'fixed' heading mode")
+	headingOffset := flag.Float64("heading-offset", 0, "angle in degrees added to the course by the 'offset' heading mode")
+	pitchKeyframes := flag.String("pitch-keyframes", "", "gimbal pitch ramp as position:pitch pairs, e.g. 0:-90,10:-45")
+	pitchKeyframeUnit := flag.String("pitch-keyframe-unit", "index", "keyframe positions as waypoint 'index' (from 0) or 'distance' in meters")
+	actionTemplate := flag.String("actions", "", "action template replacing the photo at every waypoint, e.g. 'record @ first; stoprecord @ last'")
+	minShotInterval := flag.Float64("min-shot-interval", 0, "shortest time in seconds the camera needs between photos, for planning speeds")
+	shutterSpeed := flag.Float64("shutter-speed", 0, "exposure time in seconds, with -gsd, for planning speeds within the blur limit")
+	gsd := flag.Float64("gsd", 0, "ground sample distance in meters per pixel, with -shutter-speed")
+	maxBlur := flag.Float64("max-blur", 1, "largest acceptable motion blur in pixels when planning speeds")
+	curveDeviation := flag.Float64("curve-deviation", 0, "fly smooth turns passing at most this many meters inside each corner (0 stops at every waypoint)")
+	sharpTurn := flag.Float64("sharp-turn", 60, "smallest change of course in degrees left as a sharp corner with -curve-deviation")
+	simplify := flag.Float64("simplify", 0, "remove waypoints within this many meters of the simplified path (0 disables)")
+	densify := flag.Float64("densify", 0, "insert waypoints so no leg is longer than this many meters (0 disables)")
+	excludePath := flag.String("exclude", "", "GeoJSON or KML file of no-fly zones the mission must avoid")
+	includePath := flag.String("include", "", "GeoJSON or KML file of zones the mission must stay inside")
+	geofenceAction := flag.String("geofence-action", "fail", "what to do when the mission breaks a zone: 'fail' or 'warn'")
+	maxDistance := flag.Float64("max-distance", 0, "largest allowed distance in meters of any waypoint or leg from -home (0 disables)")
+	maxAltitude := flag.Float64("max-altitude", 120, "altitude AGL in meters above which a warning is logged (0 disables)")
+	dsmPath := flag.String("dsm", "", "GeoTIFF surface model (WGS84) for checking obstacle clearance")
+	minClearance := flag.Float64("min-clearance", 15, "smallest allowed height in meters above the surface model")
+	clearanceStep := flag.Float64("clearance-step", 5, "distance in meters between surface model samples along each leg")
+	clearanceAction := flag.String("clearance-action", "fail", "what to do when the route passes too close to the surface model: 'fail' or 'warn'")
+	home := flag.String("home", "", "takeoff point as latitude,longitude[,altitude], used for the takeoff elevation of relative altitudes and for -split")
+	aircraft := flag.String("aircraft", "", "aircraft profile for the flight time and battery estimate, one of "+strings.Join(fp2lm.AircraftNames(), ", "))
+	splitPattern := flag.String("split", "", "also write one mission per battery to files named by this pattern, such as part-%d.csv (needs -home and -aircraft)")
+	outputPath := flag.String("output", "", "output file path (default stdout)")
+	profilePath := flag.String("profile", "", "also write an altitude profile of the mission to this file, as CSV if it ends in .csv and SVG otherwise")
+	demPath := flag.String("dem", "", "GeoTIFF terrain model (WGS84) for the takeoff elevation and the terrain in the altitude profile")
+	photosPath := flag.String("photos", "", "also write the predicted photo positions to this file, as GeoJSON if it ends in .geojson or .json and CSV otherwise")
+	planPath := flag.String("plan", "", "also write a plan view of the mission to this file, as PNG if it ends in .png and SVG otherwise")
+	htmlPath := flag.String("html", "", "also write an offline HTML preview of the mission to this file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  fp2lm [options] < FlightplannerMission.csv > LitchiMission.csv\n"+
+			"  fp2lm validate [options] [LitchiMission.csv]\n\nOptions:\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// Configure the conversion from the flags
+	options := fp2lm.DefaultOptions()
+	options.PhotoInterval = *interval
+	options.AltitudeMode = *altitudeMode
+	options.GimbalPitch = *pitch
+	options.Aircraft = *aircraft
+	options.Heading = fp2lm.HeadingStrategy{Mode: *headingMode, Azimuth: *headingAzimuth, Offset: *headingOffset}
+	options.PitchKeyframeUnit = *pitchKeyframeUnit
+	options.ActionTemplate = *actionTemplate
+	options.SimplifyTolerance = *simplify
+	options.DensifySpacing = *densify
+	options.GeofenceAction = *geofenceAction
+	options.MaxDistance = *maxDistance
+	if *poi != "" {
+		point, err := parsePoint(*poi)
+		if err != nil {
+			slog.Error("Error parsing point of interest", "error", err)
+			os.Exit(1)
+		}
+		options.POI = &missioncsv.POI{Latitude: point.Latitude, Longitude: point.Longitude, Altitude: point.Altitude}
+	}
+	if *pitchKeyframes != "" {
+		keyframes, err := parsePitchKeyframes(*pitchKeyframes)
+		if err != nil {
+			slog.Error("Error parsing pitch keyframes", "error", err)
+			os.Exit(1)
+		}
+		options.PitchKeyframes = keyframes
+	}
+	if *minShotInterval > 0 || *shutterSpeed > 0 || *gsd > 0 {
+		options.Camera = &fp2lm.Camera{
+			MinShotInterval: *minShotInterval,
+			ShutterSpeed:    *shutterSpeed,
+			GSD:             *gsd,
+			MaxBlur:         *maxBlur,
+		}
+	}
+	if *curveDeviation > 0 {
+		options.Curves = &fp2lm.CurvePlan{MaxDeviation: *curveDeviation, SharpTurnAngle: *sharpTurn}
+	}
+	for _, zones := range []struct {
+		path string
+		kind fp2lm.ZoneKind
+	}{{*excludePath, fp2lm.ZoneExclusion}, {*includePath, fp2lm.ZoneInclusion}} {
+		if zones.path == "" {
+			continue
+		}
+		loaded, err := fp2lm.LoadZones(zones.path, zones.kind)
+		if err != nil {
+			slog.Error("Error loading geofence zones", "error", err)
+			os.Exit(1)
+		}
+		options.Zones = append(options.Zones, loaded...)
+	}
+	homeAltitudeSet := false
+	if *home != "" {
+		point, err := parsePoint(*home)
+		if err != nil {
+			slog.Error("Error parsing home point", "error", err)
+			os.Exit(1)
+		}
+		options.Home = &point
+		homeAltitudeSet = len(strings.Split(*home, ",")) == 3
+	}
+	if *demPath != "" {
+		dem, err := geotiff.Open(*demPath)
+		if err != nil {
+			slog.Error("Error loading terrain model", "error", err)
+			os.Exit(1)
+		}
+		options.Terrain = dem
+	}
+	if *dsmPath != "" {
+		dsm, err := geotiff.Open(*dsmPath)
+		if err != nil {
+			slog.Error("Error loading surface model", "error", err)
+			os.Exit(1)
+		}
+		options.Surface = dsm
+		options.MinClearance = *minClearance
+		options.ClearanceStep = *clearanceStep
+		options.ClearanceAction = *clearanceAction
+	}
+
+	// Convert the Flight Planner mission read from stdin
+	waypoints, err := fp2lm.Convert(os.Stdin, options)
+	if err != nil {
+		slog.Error("Error converting mission", "error", err)
+		os.Exit(1)
+	}
+
+	// Report anything that would stop the mission loading or flying in Litchi
+	findings := fp2lm.Validate(waypoints, &fp2lm.ValidationOptions{MaxAltitudeAGL: *maxAltitude})
+	for _, finding := range findings {
+		if finding.Severity == fp2lm.SeverityError {
+			slog.Error("Mission check failed", "finding", finding.String())
+		} else {
+			slog.Warn("Mission check warning", "finding", finding.String())
+		}
+	}
+	if fp2lm.HasErrors(findings) {
+		slog.Error("Mission would not load or fly in Litchi; not writing it")
+		os.Exit(1)
+	}
+
+	// Write the Litchi mission to the output file or stdout
+	if *outputPath != "" {
+		err = writeMissionFile(*outputPath, waypoints)
+	} else {
+		err = fp2lm.WriteMission(os.Stdout, waypoints)
+	}
+	if err != nil {
+		slog.Error("Error writing mission", "error", err)
+		os.Exit(1)
+	}
+
+	// Split the mission into parts that can each be flown on one battery
+	if *splitPattern != "" {
+		splitHome := options.Home
+		if splitHome != nil && !homeAltitudeSet {
+			// Fly from the ground at home rather than from sea level
+			altitude, err := fp2lm.HomeAltitude(waypoints, *splitHome, options.Terrain)
+			if err != nil {
+				slog.Error("Error finding home altitude", "error", err)
+				os.Exit(1)
+			}
+			splitHome = &missioncsv.Point{Latitude: splitHome.Latitude, Longitude: splitHome.Longitude, Altitude: altitude}
+		}
+		if err := writeBatteryParts(*splitPattern, waypoints, splitHome, *aircraft); err != nil {
+			slog.Error("Error splitting mission by battery", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Write the photo positions, profile, plan view and preview of the same waypoints
+	if *photosPath != "" {
+		if err := writePhotos(*photosPath, waypoints); err != nil {
+			slog.Error("Error writing photo positions", "error", err)
+			os.Exit(1)
+		}
+	}
+	if *profilePath != "" {
+		profileOptions := missionview.DefaultProfileOptions()
+		profileOptions.MinClearance = *minClearance
+		profileOptions.Terrain = options.Terrain
+		profileOptions.Home = options.Home
+		if err := writeProfile(*profilePath, waypoints, profileOptions); err != nil {
+			slog.Error("Error writing altitude profile", "error", err)
+			os.Exit(1)
+		}
+	}
+	if *planPath != "" {
+		if err := writePlanView(*planPath, waypoints); err != nil {
+			slog.Error("Error writing plan view", "error", err)
+			os.Exit(1)
+		}
+	}
+	if *htmlPath != "" {
+		if err := writeHTMLPreview(*htmlPath, *outputPath, waypoints); err != nil {
+			slog.Error("Error writing HTML preview", "error", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// parsePoint parses a point given as "latitude,longitude" or
+// "latitude,longitude,altitude"
+func parsePoint(s string) (missioncsv.Point, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 2 && len(fields) != 3 {
+		return missioncsv.Point{}, fmt.Errorf("expected latitude,longitude[,altitude], got %q", s)
+	}
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+	if err != nil {
+		return missioncsv.Point{}, fmt.Errorf("latitude: %w", err)
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+	if err != nil {
+		return missioncsv.Point{}, fmt.Errorf("longitude: %w", err)
+	}
+	point := missioncsv.Point{Latitude: latitude, Longitude: longitude}
+	if len(fields) == 3 {
+		if point.Altitude, err = strconv.ParseFloat(strings.TrimSpace(fields[2]), 64); err != nil {
+			return missioncsv.Point{}, fmt.Errorf("altitude: %w", err)
+		}
+	}
+	return point, nil
+}
+
+// parsePitchKeyframes parses keyframes given as comma-separated position:pitch pairs
+func parsePitchKeyframes(s string) ([]fp2lm.PitchKeyframe, error) {
+	var keyframes []fp2lm.PitchKeyframe
+	for _, pair := range strings.Split(s, ",") {
+		fields := strings.Split(pair, ":")
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("expected position:pitch, got %q", pair)
+		}
+		position, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("keyframe position: %w", err)
+		}
+		pitch, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("keyframe pitch: %w", err)
+		}
+		keyframes = append(keyframes, fp2lm.PitchKeyframe{Position: position, Pitch: pitch})
+	}
+	return keyframes, nil
+}
+
+// writeBatteryParts splits the mission into parts flown from home on one battery each
+// and writes every part to a file named by pattern, numbered from 1
+func writeBatteryParts(pattern string, waypoints []*missioncsv.LitchiWaypoint, home *missioncsv.Point, aircraft string) error {
+	if !strings.Contains(pattern, "%d") {
+		return fmt.Errorf("split pattern must contain %%d for the part number, got %q", pattern)
+	}
+	if home == nil || aircraft == "" {
+		return fmt.Errorf("splitting by battery needs -home and -aircraft")
+	}
+	profile, err := fp2lm.LookupAircraft(aircraft)
+	if err != nil {
+		return err
+	}
+	parts, err := fp2lm.SplitByBattery(waypoints, *home, profile)
+	if err != nil {
+		return err
+	}
+
+	for i, part := range parts {
+		path := fmt.Sprintf(pattern, i+1)
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := fp2lm.WriteMission(file, part); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		slog.Info("Wrote battery part", "part", i+1, "waypoints", len(part), "path", path)
+	}
+	return nil
+}
+
+// writeMissionFile writes the Litchi mission to path, closing the file before returning
+func writeMissionFile(path string, waypoints []*missioncsv.LitchiWaypoint) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := fp2lm.WriteMission(file, waypoints); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writePhotos writes the predicted photo positions to path as GeoJSON or CSV, chosen
+// by its extension
+func writePhotos(path string, waypoints []*missioncsv.LitchiWaypoint) error {
+	write := fp2lm.WritePhotosCSV
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".geojson", ".json":
+		write = fp2lm.WritePhotosGeoJSON
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file, fp2lm.SimulatePhotos(waypoints)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeProfile writes the altitude profile to path as CSV or SVG, chosen by its extension
+func writeProfile(path string, waypoints []*missioncsv.LitchiWaypoint, options *missionview.ProfileOptions) error {
+	write := missionview.WriteProfileSVG
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		write = missionview.WriteProfileCSV
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file, waypoints, options); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writePlanView writes the plan view to path as PNG or SVG, chosen by its extension
+func writePlanView(path string, waypoints []*missioncsv.LitchiWaypoint) error {
+	write := missionview.WritePlanSVG
+	if strings.EqualFold(filepath.Ext(path), ".png") {
+		write = missionview.WritePlanPNG
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file, waypoints, missionview.DefaultPlanOptions()); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeHTMLPreview writes the HTML preview to path, titled after the mission file
+func writeHTMLPreview(path, missionPath string, waypoints []*missioncsv.LitchiWaypoint) error {
+	title := "Litchi mission"
+	if missionPath != "" {
+		title = filepath.Base(missionPath)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := missionview.WriteHTML(file, title, waypoints); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// runValidate checks an existing Litchi mission CSV and prints every finding,
+// returning the exit status: 1 if there are errors, 0 otherwise
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	maxAltitude := flags.Float64("max-altitude", 120, "altitude AGL in meters above which a warning is reported (0 disables)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage:\n  fp2lm validate [options] [LitchiMission.csv]\n\n"+
+			"Reads the mission from stdin when no file is given.\n\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	// Read the mission from the named file or stdin
+	var input io.Reader = os.Stdin
+	if flags.NArg() > 0 {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			slog.Error("Error opening mission", "error", err)
+			return 1
+		}
+		defer file.Close()
+		input = file
+	}
+	waypoints, err := missioncsv.NewReader(input).ReadLitchiMission()
+	if err != nil {
+		slog.Error("Error reading mission", "error", err)
+		return 1
+	}
+
+	findings := fp2lm.Validate(waypoints, &fp2lm.ValidationOptions{MaxAltitudeAGL: *maxAltitude})
+	for _, finding := range findings {
+		fmt.Println(finding)
+	}
+	if fp2lm.HasErrors(findings) {
+		return 1
+	}
+	if len(findings) == 0 {
+		fmt.Printf("Mission is valid: %d waypoints\n", len(waypoints))
+	}
+	return 0
+}
@@ -0,0 +1,323 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// cornerAngle is the smallest change of course in degrees that makes a waypoint a corner
+const cornerAngle = 30
+
+// simpleActions maps the template names of actions that take no value to their types
+var simpleActions = map[string]missioncsv.ActionType{
+	"photo":      missioncsv.ActionTakePhoto,
+	"record":     missioncsv.ActionStartRecording,
+	"stoprecord": missioncsv.ActionStopRecording,
+	"focus":      missioncsv.ActionFocus,
+}
+
+// ActionTemplate describes which actions to perform at which waypoints
+//
+// A template is a list of rules separated by semicolons. Each rule names one or more
+// actions joined by "+", then "@" and the waypoints they apply to:
+//
+//	record @ first; stoprecord @ last; stay 2s + photo @ every 5; rotate @ corners
+//
+// Actions:
+//   - photo: Take a photo
+//   - record, stoprecord: Start or stop video recording
+//   - stay <duration>: Hover for a duration such as "2s" or "500ms" (up to 32s)
+//   - rotate [degrees]: Rotate the aircraft to a heading; without a value, to the
+//     waypoint's own heading
+//   - tilt <degrees>: Tilt the camera (between -90 and 30)
+//   - zoom <ratio>: Set the camera zoom ratio (between 1 and 30)
+//   - focus: Trigger autofocus
+//
+// Selectors:
+//   - all, first, last: Every waypoint, or the first or last one
+//   - corners: Waypoints where the course changes by 30 degrees or more
+//   - every <n>: Every nth waypoint, counting from 1 (the nth, 2nth, ...)
+//   - <n>: The nth waypoint, counting from 1
+//
+// Actions from several rules that select the same waypoint are performed in rule order.
+type ActionTemplate struct {
+	rules []actionRule
+}
+
+// actionRule applies a list of actions to the waypoints chosen by a selector
+type actionRule struct {
+	selector actionSelector
+	actions  []actionSpec
+}
+
+// actionSelector chooses waypoints by kind ("all", "first", "last", "corners",
+// "every" or "number"), with n used by "every" and "number"
+type actionSelector struct {
+	kind string
+	n    int
+}
+
+// actionSpec is a single action in a rule. When useHeading is set the parameter is
+// taken from the waypoint's heading at expansion time.
+type actionSpec struct {
+	action     missioncsv.Action
+	useHeading bool
+}
+
+// ParseActionTemplate parses an action template, reporting the first malformed rule
+func ParseActionTemplate(template string) (*ActionTemplate, error) {
+	t := &ActionTemplate{}
+	for _, ruleText := range strings.Split(template, ";") {
+		ruleText = strings.TrimSpace(ruleText)
+		if ruleText == "" {
+			continue
+		}
+
+		parts := strings.Split(ruleText, "@")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("action rule %q must have the form '<actions> @ <waypoints>'", ruleText)
+		}
+
+		selector, err := parseActionSelector(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("action rule %q: %w", ruleText, err)
+		}
+
+		rule := actionRule{selector: selector}
+		for _, actionText := range strings.Split(parts[0], "+") {
+			spec, err := parseActionSpec(actionText)
+			if err != nil {
+				return nil, fmt.Errorf("action rule %q: %w", ruleText, err)
+			}
+			rule.actions = append(rule.actions, spec)
+		}
+		t.rules = append(t.rules, rule)
+	}
+	return t, nil
+}
+
+// parseActionSelector parses the waypoint selector of a rule
+func parseActionSelector(text string) (actionSelector, error) {
+	fields := strings.Fields(strings.ToLower(text))
+	switch {
+	case len(fields) == 1 && (fields[0] == "all" || fields[0] == "first" || fields[0] == "last" || fields[0] == "corners"):
+		return actionSelector{kind: fields[0]}, nil
+	case len(fields) == 2 && fields[0] == "every":
+		n, err := strconv.Atoi(strings.TrimRight(fields[1], "stndrh"))
+		if err != nil || n < 1 {
+			return actionSelector{}, fmt.Errorf("'every' needs a positive count, got %q", fields[1])
+		}
+		return actionSelector{kind: "every", n: n}, nil
+	case len(fields) == 1:
+		n, err := strconv.Atoi(fields[0])
+		if err != nil || n < 1 {
+			return actionSelector{}, fmt.Errorf("unknown waypoint selector %q", strings.TrimSpace(text))
+		}
+		return actionSelector{kind: "number", n: n}, nil
+	default:
+		return actionSelector{}, fmt.Errorf("unknown waypoint selector %q", strings.TrimSpace(text))
+	}
+}
+
+// parseActionSpec parses a single action and its parameter
+func parseActionSpec(text string) (actionSpec, error) {
+	fields := strings.Fields(strings.ToLower(text))
+	if len(fields) == 0 || len(fields) > 2 {
+		return actionSpec{}, fmt.Errorf("malformed action %q", strings.TrimSpace(text))
+	}
+	name := fields[0]
+	arg := ""
+	if len(fields) == 2 {
+		arg = fields[1]
+	}
+
+	var spec actionSpec
+	switch name {
+	case "photo", "record", "stoprecord", "focus":
+		if arg != "" {
+			return actionSpec{}, fmt.Errorf("action %q does not take a value", name)
+		}
+		spec.action.Type = simpleActions[name]
+	case "stay":
+		millis, err := parseStayDuration(arg)
+		if err != nil {
+			return actionSpec{}, err
+		}
+		spec.action = missioncsv.Action{Type: missioncsv.ActionStay, Param: millis}
+	case "rotate":
+		spec.action.Type = missioncsv.ActionRotateAircraft
+		if arg == "" {
+			spec.useHeading = true
+			break
+		}
+		degrees, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return actionSpec{}, fmt.Errorf("rotate needs a heading in degrees, got %q", arg)
+		}
+		spec.action.Param = rotationParam(degrees)
+	case "tilt", "zoom":
+		value, err := parseActionParam(arg)
+		if err != nil {
+			return actionSpec{}, fmt.Errorf("%s needs a value, got %q", name, arg)
+		}
+		spec.action = missioncsv.Action{Type: missioncsv.ActionTiltCamera, Param: value}
+		if name == "zoom" {
+			spec.action.Type = missioncsv.ActionZoom
+		}
+	default:
+		return actionSpec{}, fmt.Errorf("unknown action %q", name)
+	}
+
+	if err := spec.action.Validate(); err != nil {
+		return actionSpec{}, err
+	}
+	return spec, nil
+}
+
+// parseActionParam parses a numeric action parameter, rounding it to a whole number
+func parseActionParam(text string) (int16, error) {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, err
+	}
+	if value < math.MinInt16 || value > math.MaxInt16 {
+		return 0, fmt.Errorf("value %s is out of range", text)
+	}
+	return int16(math.Round(value)), nil
+}
+
+// parseStayDuration parses a hover duration such as "2s", "1.5s" or "500ms" into
+// milliseconds. The range is checked by missioncsv.Action.Validate.
+func parseStayDuration(text string) (int16, error) {
+	var millis float64
+	var err error
+	switch {
+	case strings.HasSuffix(text, "ms"):
+		millis, err = strconv.ParseFloat(strings.TrimSuffix(text, "ms"), 64)
+	case strings.HasSuffix(text, "s"):
+		millis, err = strconv.ParseFloat(strings.TrimSuffix(text, "s"), 64)
+		millis *= 1000
+	default:
+		return 0, fmt.Errorf("stay needs a duration such as 2s or 500ms, got %q", text)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("stay needs a duration such as 2s or 500ms, got %q", text)
+	}
+	if millis < 0 || millis > math.MaxInt16 {
+		return 0, fmt.Errorf("stay duration %q is out of range", text)
+	}
+	return int16(math.Round(millis)), nil
+}
+
+// rotationParam converts a heading in degrees to a whole-degree rotation parameter (0-359)
+func rotationParam(degrees float64) int16 {
+	return int16(math.Round(normalizeHeading(math.Round(degrees)))) % 360
+}
+
+// Expand replaces the actions of every waypoint with those selected by the template
+//
+// Headings must already be set, since "rotate" without a value uses each waypoint's
+// heading. An error is returned if any waypoint would receive more than
+// missioncsv.MaxActions actions.
+func (t *ActionTemplate) Expand(waypoints []*missioncsv.LitchiWaypoint) error {
+	rotations, err := t.expand(waypoints)
+	if err != nil {
+		return err
+	}
+	rotations.apply()
+	return nil
+}
+
+// headingRotation is a "rotate" action, by waypoint and action index, whose parameter
+// is the waypoint's heading
+type headingRotation struct {
+	wp     *missioncsv.LitchiWaypoint
+	action int
+}
+
+// headingRotations lists the rotations waiting for headings to be set
+type headingRotations []headingRotation
+
+// apply sets each rotation to the current heading of its waypoint
+func (r headingRotations) apply() {
+	for _, rotation := range r {
+		rotation.wp.Actions[rotation.action].Param = rotationParam(float64(rotation.wp.Heading))
+	}
+}
+
+// expand replaces the actions of every waypoint like Expand, but leaves rotations to
+// the waypoint's heading for the caller to apply once headings are set. Convert
+// expands the template before simplifying, so selectors count the waypoints as
+// planned, and applies the rotations after computing headings.
+func (t *ActionTemplate) expand(waypoints []*missioncsv.LitchiWaypoint) (headingRotations, error) {
+	corners := cornerWaypoints(waypoints)
+
+	expanded := make([][]missioncsv.Action, len(waypoints))
+	var rotations headingRotations
+	for _, rule := range t.rules {
+		for i, wp := range waypoints {
+			if !rule.selector.matches(i, len(waypoints), corners) {
+				continue
+			}
+			for _, spec := range rule.actions {
+				if spec.useHeading {
+					rotations = append(rotations, headingRotation{wp: wp, action: len(expanded[i])})
+				}
+				expanded[i] = append(expanded[i], spec.action)
+			}
+		}
+	}
+
+	for i, actions := range expanded {
+		if err := missioncsv.ValidateActions(actions); err != nil {
+			return nil, fmt.Errorf("waypoint %d: %w", i+1, err)
+		}
+	}
+	for i, wp := range waypoints {
+		wp.Actions = expanded[i]
+	}
+	return rotations, nil
+}
+
+// matches reports whether the waypoint at index (from 0) out of count is selected
+func (s actionSelector) matches(index, count int, corners map[int]bool) bool {
+	switch s.kind {
+	case "all":
+		return true
+	case "first":
+		return index == 0
+	case "last":
+		return index == count-1
+	case "corners":
+		return corners[index]
+	case "every":
+		return (index+1)%s.n == 0
+	case "number":
+		return index+1 == s.n
+	}
+	return false
+}
+
+// cornerWaypoints returns the indices of interior waypoints where the course changes
+// by at least cornerAngle degrees
+func cornerWaypoints(waypoints []*missioncsv.LitchiWaypoint) map[int]bool {
+	corners := map[int]bool{}
+	if len(waypoints) < 3 {
+		return corners
+	}
+	courses := legCourses(waypoints)
+	for i := 1; i < len(courses); i++ {
+		if turnAngle(courses[i-1], courses[i]) >= cornerAngle {
+			corners[i] = true
+		}
+	}
+	return corners
+}
+
+// turnAngle returns the absolute change in degrees between two headings (0-180)
+func turnAngle(from, to float64) float64 {
+	return math.Abs(math.Mod(to-from+540, 360) - 180)
+}
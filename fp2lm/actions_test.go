@@ -0,0 +1,68 @@
+package fp2lm_test
+
+import (
+	"bytes"
+	"flightplan2litchimission/fp2lm"
+	"strings"
+	"testing"
+)
+
+// cornerInput is a Flight Planner CSV flying east, turning north at waypoint 3
+const cornerInput = "Waypoint Number,X [m],Y [m],Alt. ASL [m],Alt. AGL [m],xcoord,ycoord\n" +
+	"1,0,0,60,40,-89.0000,43.0000\n" +
+	"2,0,0,60,40,-88.9990,43.0000\n" +
+	"3,0,0,60,40,-88.9980,43.0000\n" +
+	"4,0,0,60,40,-88.9980,43.0010\n"
+
+// TestActionTemplate checks that template rules expand into per-waypoint actions
+func TestActionTemplate(t *testing.T) {
+	options := fp2lm.DefaultOptions()
+	options.ActionTemplate = "record @ first; stoprecord @ last; stay 2s + photo @ every 2nd; rotate @ corners; tilt -45 @ 3"
+
+	var out bytes.Buffer
+	if err := fp2lm.Process(strings.NewReader(cornerInput), &out, options); err != nil {
+		t.Fatalf("Process returned error: %v", err)
+	}
+
+	// Each row holds 15 type/param pairs starting at column 8
+	expected := []string{
+		"2,0,0,0",            // start recording
+		"0,2000,1,0,0,0",     // stay 2s, photo
+		"4,0,5,-45,0,0",      // rotate to the outgoing heading (north), tilt
+		"3,0,0,2000,1,0,0,0", // stop recording, stay 2s, photo
+	}
+	rows := outputRows(t, out.String())
+	for i, row := range rows {
+		actions := strings.Join(row[8:38], ",")
+		if !strings.HasPrefix(actions, expected[i]) {
+			t.Errorf("waypoint %d: expected actions starting %s, got %s", i, expected[i], actions)
+		}
+	}
+}
+
+// TestActionTemplateInvalid checks that malformed templates and overfull waypoints are rejected
+func TestActionTemplateInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{"Missing selector", "photo"},
+		{"Unknown action", "dance @ all"},
+		{"Unknown selector", "photo @ middle"},
+		{"Stay too long", "stay 40s @ first"},
+		{"Tilt out of range", "tilt 45 @ first"},
+		{"Too many actions", "photo+photo+photo+photo+photo+photo+photo+photo @ all; photo+photo+photo+photo+photo+photo+photo+photo @ first"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := fp2lm.DefaultOptions()
+			options.ActionTemplate = tt.template
+
+			var out bytes.Buffer
+			if err := fp2lm.Process(strings.NewReader(cornerInput), &out, options); err == nil {
+				t.Errorf("expected an error for template %q", tt.template)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+)
+
+// ClearanceViolation is a point along the route where the planned altitude leaves less
+// than the minimum clearance above the surface
+type ClearanceViolation struct {
+	// Leg is the index (from 0) of the waypoint starting the leg
+	Leg int
+
+	// Latitude and Longitude locate the sampled point
+	Latitude  float64
+	Longitude float64
+
+	// Altitude is the planned altitude above sea level at the point, and Surface the
+	// elevation of the surface beneath it, both in meters
+	Altitude float64
+	Surface  float64
+
+	// Deficit is how many meters short of the minimum clearance the point is
+	Deficit float64
+}
+
+// String describes the violation with the leg numbered from 1
+func (v ClearanceViolation) String() string {
+	return fmt.Sprintf("leg %d at %.7f, %.7f: %.1f m above the surface, %.1f m short",
+		v.Leg+1, v.Latitude, v.Longitude, v.Altitude-v.Surface, v.Deficit)
+}
+
+// CheckClearance samples a surface model along every leg and reports each point where
+// the aircraft would pass less than minClearance meters above the surface
+//
+// Parameters:
+//   - waypoints: The mission waypoints in flight order
+//   - surface: Elevation model of the surface, such as a digital surface model that
+//     includes trees and buildings
+//   - takeoffElevation: Elevation in meters of the takeoff point, added to relative
+//     altitudes; see TakeoffElevation
+//   - minClearance: The smallest allowed height in meters above the surface
+//   - step: The distance in meters between samples along each leg
+//
+// Points are placed by SampleRoute, so they include the waypoints themselves and the
+// altitude between waypoints is interpolated above sea level.
+func CheckClearance(waypoints []*missioncsv.LitchiWaypoint, surface ElevationModel, takeoffElevation, minClearance, step float64) ([]ClearanceViolation, error) {
+	samples, err := SampleRoute(waypoints, takeoffElevation, step)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []ClearanceViolation
+	for _, sample := range samples {
+		elevation, err := surface.Elevation(sample.Latitude, sample.Longitude)
+		if err != nil {
+			return nil, fmt.Errorf("leg %d: %w", sample.Leg+1, err)
+		}
+		if deficit := minClearance - (sample.AltitudeASL - elevation); deficit > 0 {
+			violations = append(violations, ClearanceViolation{
+				Leg:       sample.Leg,
+				Latitude:  sample.Latitude,
+				Longitude: sample.Longitude,
+				Altitude:  sample.AltitudeASL,
+				Surface:   elevation,
+				Deficit:   deficit,
+			})
+		}
+	}
+	return violations, nil
+}
+
+// clearanceError summarizes clearance violations, naming the worst
+func clearanceError(violations []ClearanceViolation, minClearance float64) error {
+	worst := violations[0]
+	for _, v := range violations[1:] {
+		if v.Deficit > worst.Deficit {
+			worst = v
+		}
+	}
+	subject := fmt.Sprintf("%d points along the route have", len(violations))
+	if len(violations) == 1 {
+		subject = "1 point along the route has"
+	}
+	return fmt.Errorf("%s less than %.1f m clearance above the surface; the worst, on %s",
+		subject, minClearance, worst)
+}
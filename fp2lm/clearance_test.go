@@ -0,0 +1,130 @@
+package fp2lm_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestCheckClearance checks that points over the hill are reported with their deficit
+func TestCheckClearance(t *testing.T) {
+	// 30 m above a takeoff point on the 100 m plain, so 10 m above the hill
+	waypoints := route([2]float64{0, 0}, [2]float64{0, 0.01})
+	for _, wp := range waypoints {
+		wp.Point.Altitude = 30
+	}
+
+	violations, err := fp2lm.CheckClearance(waypoints, hill{}, 100, 15, 50)
+	if err != nil {
+		t.Fatalf("CheckClearance returned error: %v", err)
+	}
+	if len(violations) == 0 {
+		t.Fatal("expected violations over the hill")
+	}
+	for _, v := range violations {
+		if v.Leg != 0 || math.Abs(v.Longitude-0.005) >= 0.001 {
+			t.Errorf("expected violations only over the hill, got %s", v)
+		}
+		if math.Abs(v.Deficit-5) > 1e-9 || v.Altitude != 130 || v.Surface != 120 {
+			t.Errorf("expected a 5 m deficit at 130 m over 120 m, got %+v", v)
+		}
+	}
+
+	if violations, _ := fp2lm.CheckClearance(waypoints, hill{}, 100, 10, 50); len(violations) != 0 {
+		t.Errorf("expected no violations at 10 m clearance, got %v", violations)
+	}
+
+	// Absolute altitudes ignore the takeoff elevation
+	for _, wp := range waypoints {
+		wp.AltitudeMode = 0
+		wp.Point.Altitude = 125
+	}
+	violations, err = fp2lm.CheckClearance(waypoints, hill{}, 100, 10, 50)
+	if err != nil || len(violations) == 0 || math.Abs(violations[0].Deficit-5) > 1e-9 {
+		t.Errorf("expected 5 m deficits at absolute altitudes, got %v, %v", violations, err)
+	}
+
+	// A leg from a relative to an absolute waypoint climbs from 130 m to 160 m above
+	// sea level, so it clears the near edge of the hill, 40% along, by 22 m
+	waypoints[0].AltitudeMode = 1
+	waypoints[0].Point.Altitude = 30
+	waypoints[1].Point.Altitude = 160
+	violations, err = fp2lm.CheckClearance(waypoints, hill{}, 100, 23, 10)
+	if err != nil || len(violations) == 0 {
+		t.Fatalf("expected violations on the mixed leg, got %v, %v", violations, err)
+	}
+	for _, v := range violations {
+		if want := 130 + 30*(v.Longitude/0.01); math.Abs(v.Altitude-want) > 0.5 {
+			t.Errorf("expected %.1f m interpolated above sea level, got %+v", want, v)
+		}
+	}
+	if violations, _ := fp2lm.CheckClearance(waypoints, hill{}, 100, 21, 10); len(violations) != 0 {
+		t.Errorf("expected no violations at 21 m clearance on the mixed leg, got %v", violations)
+	}
+
+	if _, err := fp2lm.CheckClearance(waypoints, noData{}, 0, 10, 50); err == nil {
+		t.Error("expected an error without surface data")
+	}
+	if _, err := fp2lm.CheckClearance(waypoints, hill{}, 0, 10, 0); err == nil {
+		t.Error("expected an error for a zero step")
+	}
+}
+
+// building is a surface model with a 60 m building on the plain between waypoints 2 and 3
+// of eastboundInput
+type building struct{}
+
+func (building) Elevation(latitude, longitude float64) (float64, error) {
+	if longitude > -88.9986 && longitude < -88.9984 {
+		return 80, nil
+	}
+	return 20, nil
+}
+
+// TestProcessWithSurface checks that Process stops when the route passes too close to
+// the surface
+func TestProcessWithSurface(t *testing.T) {
+	options := fp2lm.DefaultOptions()
+	options.Surface = building{}
+	options.MinClearance = 10
+
+	// Relative altitudes need a takeoff elevation, which the roof under the first
+	// waypoint must not stand in for
+	var output strings.Builder
+	err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options)
+	if err == nil || !strings.Contains(err.Error(), "need a home point or a terrain model") {
+		t.Errorf("expected an error without a home point, got %v", err)
+	}
+
+	options.Home = &missioncsv.Point{Latitude: 43, Longitude: -89}
+	output.Reset()
+	err = fp2lm.Process(strings.NewReader(eastboundInput), &output, options)
+	if err == nil || !strings.Contains(err.Error(), "less than 10.0 m clearance above the surface; the worst, on leg 2") ||
+		!strings.Contains(err.Error(), "-20.0 m above the surface, 30.0 m short") {
+		t.Errorf("expected a clearance error on leg 2, got %v", err)
+	}
+
+	options.ClearanceAction = "warn"
+	output.Reset()
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options); err != nil {
+		t.Errorf("expected only warnings with the warn action, got %v", err)
+	}
+	if output.Len() == 0 {
+		t.Error("expected a mission with the warn action")
+	}
+
+	options.ClearanceAction = "ignore"
+	output.Reset()
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options); err == nil {
+		t.Error("expected an error for an unknown clearance action")
+	}
+
+	options.ClearanceAction = "fail"
+	options.Surface = noData{}
+	output.Reset()
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options); err == nil {
+		t.Error("expected an error without surface data")
+	}
+}
@@ -0,0 +1,100 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+)
+
+// Curve size limits in meters accepted by DJI aircraft
+const (
+	minCurveSize = 0.2
+	maxCurveSize = 1000
+)
+
+// CurvePlan configures smooth turns at waypoints so the aircraft does not stop at each one
+type CurvePlan struct {
+	// MaxDeviation is how far in meters the aircraft may pass inside a corner. Gentle
+	// turns get large curves, tighter turns smaller ones.
+	MaxDeviation float64
+
+	// SharpTurnAngle is the smallest change of course in degrees that is left as a
+	// sharp corner, such as the turns at the ends of survey lines where photos must
+	// be taken exactly at the waypoint
+	SharpTurnAngle float64
+}
+
+// DefaultCurvePlan returns recommended settings for smooth turns
+//
+// The default settings are:
+// - MaxDeviation: 5 meters
+// - SharpTurnAngle: 60 degrees
+func DefaultCurvePlan() *CurvePlan {
+	return &CurvePlan{
+		MaxDeviation:   5,
+		SharpTurnAngle: 60,
+	}
+}
+
+// Validate checks that the plan's settings are usable
+func (p CurvePlan) Validate() error {
+	if p.MaxDeviation <= 0 {
+		return fmt.Errorf("curve deviation must be positive, got %.1f", p.MaxDeviation)
+	}
+	if p.SharpTurnAngle <= 0 || p.SharpTurnAngle > 180 {
+		return fmt.Errorf("sharp turn angle must be between 0 and 180 degrees, got %.1f", p.SharpTurnAngle)
+	}
+	return nil
+}
+
+// Apply sets the curve size of every waypoint
+//
+// A curve of size s starts s meters before the waypoint and ends s meters after it, so
+// for a change of course θ the aircraft passes s * tan(θ/4) meters inside the corner.
+// Each curve is the largest that:
+//   - Keeps that deviation within MaxDeviation
+//   - Uses at most half of each adjacent leg, so neighbouring curves never overlap
+//
+// The first and last waypoints, turns of SharpTurnAngle or more, and curves too small
+// for the aircraft to fly are left at 0 so the aircraft stops at the waypoint.
+func (p CurvePlan) Apply(waypoints []*missioncsv.LitchiWaypoint) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if len(waypoints) == 0 {
+		return nil
+	}
+
+	waypoints[0].CurveSize = 0
+	waypoints[len(waypoints)-1].CurveSize = 0
+	if len(waypoints) < 3 {
+		return nil
+	}
+
+	courses := legCourses(waypoints)
+	for i := 1; i < len(waypoints)-1; i++ {
+		wp := waypoints[i]
+		wp.CurveSize = 0
+
+		turn := turnAngle(courses[i-1], courses[i])
+		if turn >= p.SharpTurnAngle {
+			continue
+		}
+
+		prev, next := waypoints[i-1], waypoints[i+1]
+		prevLeg := Distance(prev.Point.Latitude, prev.Point.Longitude, wp.Point.Latitude, wp.Point.Longitude)
+		nextLeg := Distance(wp.Point.Latitude, wp.Point.Longitude, next.Point.Latitude, next.Point.Longitude)
+
+		size := math.Min(math.Min(prevLeg, nextLeg)/2, maxCurveSize)
+		if deviation := math.Tan(turn * math.Pi / 180 / 4); deviation > 0 {
+			size = math.Min(size, p.MaxDeviation/deviation)
+		}
+		// Round down so the written value never makes neighbouring curves overlap
+		size = math.Floor(size*10) / 10
+		if size < minCurveSize {
+			continue
+		}
+		wp.CurveSize = float32(size)
+	}
+	return nil
+}
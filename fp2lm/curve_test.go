@@ -0,0 +1,46 @@
+package fp2lm_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"math"
+	"testing"
+)
+
+// TestCurvePlan checks curve sizes for straight, gentle and sharp turns
+func TestCurvePlan(t *testing.T) {
+	// Legs of about 110.6 m: straight on, a 30 degree turn left, then a 90 degree turn
+	lat30 := 0.001 * math.Cos(30*math.Pi/180)
+	lon30 := 0.001 * math.Sin(30*math.Pi/180)
+	waypoints := route(
+		[2]float64{0, 0},
+		[2]float64{0.001, 0},
+		[2]float64{0.002, 0},
+		[2]float64{0.002 + lat30, -lon30},
+		[2]float64{0.002 + lat30 - lon30, -lon30 - lat30},
+	)
+
+	if err := fp2lm.DefaultCurvePlan().Apply(waypoints); err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+
+	expected := []float64{
+		0,    // first waypoint
+		55.2, // straight: half the shorter leg
+		37.9, // 30 degrees: 5 m / tan(7.5 degrees)
+		0,    // 90 degrees: sharp corner
+		0,    // last waypoint
+	}
+	for i, wp := range waypoints {
+		if math.Abs(float64(wp.CurveSize)-expected[i]) > 0.15 {
+			t.Errorf("waypoint %d: expected curve size %.1f, got %.1f", i, expected[i], wp.CurveSize)
+		}
+	}
+}
+
+// TestCurvePlanInvalid checks that unusable settings are rejected
+func TestCurvePlanInvalid(t *testing.T) {
+	plan := fp2lm.CurvePlan{MaxDeviation: 0, SharpTurnAngle: 60}
+	if err := plan.Apply(lShapedRoute()); err == nil {
+		t.Error("expected an error for zero deviation")
+	}
+}
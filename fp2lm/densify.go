@@ -0,0 +1,77 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+)
+
+// ElevationModel provides ground or surface elevations, such as from a digital
+// elevation model, for terrain following and clearance checks
+type ElevationModel interface {
+	// Elevation returns the elevation in meters above sea level at a coordinate
+	Elevation(latitude, longitude float64) (float64, error)
+}
+
+// Densify inserts intermediate waypoints so that no leg is longer than spacing
+//
+// Parameters:
+//   - waypoints: The mission waypoints in flight order
+//   - spacing: The longest allowed leg in meters
+//   - terrain: Optional elevation model used to follow the ground, may be nil
+//
+// Intermediate waypoints are spread evenly along the geodesic between the ends of
+// each long leg. They copy the settings of the waypoint that starts the leg, but carry
+// no actions, no curve size, and a heading toward the end of the leg from
+// CalculateBearing. Without a terrain model their altitude is interpolated linearly.
+// With one, the height above the ground is interpolated instead, so the inserted
+// waypoints follow the terrain between the ends of the leg in either altitude mode.
+func Densify(waypoints []*missioncsv.LitchiWaypoint, spacing float64, terrain ElevationModel) ([]*missioncsv.LitchiWaypoint, error) {
+	if spacing <= 0 {
+		return nil, fmt.Errorf("densify spacing must be positive, got %.1f", spacing)
+	}
+	if len(waypoints) < 2 {
+		return waypoints, nil
+	}
+
+	densified := []*missioncsv.LitchiWaypoint{waypoints[0]}
+	for i := 1; i < len(waypoints); i++ {
+		start, end := waypoints[i-1], waypoints[i]
+		distance := Distance(start.Point.Latitude, start.Point.Longitude, end.Point.Latitude, end.Point.Longitude)
+		segments := int(math.Ceil(distance / spacing))
+
+		// Terrain following interpolates the height above ground at the leg's ends
+		var startGround, endGround float64
+		if terrain != nil && segments > 1 {
+			var err error
+			if startGround, err = terrain.Elevation(start.Point.Latitude, start.Point.Longitude); err != nil {
+				return nil, fmt.Errorf("waypoint %d: %w", i, err)
+			}
+			if endGround, err = terrain.Elevation(end.Point.Latitude, end.Point.Longitude); err != nil {
+				return nil, fmt.Errorf("waypoint %d: %w", i+1, err)
+			}
+		}
+
+		for k := 1; k < segments; k++ {
+			fraction := float64(k) / float64(segments)
+			wp := *start
+			wp.Point.Latitude, wp.Point.Longitude = IntermediatePoint(start.Point.Latitude, start.Point.Longitude,
+				end.Point.Latitude, end.Point.Longitude, fraction)
+			wp.Point.Altitude = start.Point.Altitude + (end.Point.Altitude-start.Point.Altitude)*fraction
+			if terrain != nil {
+				ground, err := terrain.Elevation(wp.Point.Latitude, wp.Point.Longitude)
+				if err != nil {
+					return nil, fmt.Errorf("leg %d: %w", i, err)
+				}
+				wp.Point.Altitude += ground - (startGround + (endGround-startGround)*fraction)
+			}
+			wp.Heading = float32(CalculateBearing(wp.Point.Latitude, wp.Point.Longitude,
+				end.Point.Latitude, end.Point.Longitude))
+			wp.CurveSize = 0
+			wp.Actions = nil
+			densified = append(densified, &wp)
+		}
+		densified = append(densified, end)
+	}
+	return densified, nil
+}
@@ -0,0 +1,124 @@
+package fp2lm_test
+
+import (
+	"errors"
+	"flightplan2litchimission/fp2lm"
+	"math"
+	"strings"
+	"testing"
+)
+
+// slope is an elevation model rising 10 m for every 0.001 degrees of longitude
+type slope struct{}
+
+func (slope) Elevation(latitude, longitude float64) (float64, error) {
+	return 100 + longitude*10000, nil
+}
+
+// hill is an elevation model with a 20 m bump in the middle of a flat eastbound leg
+type hill struct{}
+
+func (hill) Elevation(latitude, longitude float64) (float64, error) {
+	if math.Abs(longitude-0.005) < 0.001 {
+		return 120, nil
+	}
+	return 100, nil
+}
+
+// noData is an elevation model with no coverage
+type noData struct{}
+
+func (noData) Elevation(latitude, longitude float64) (float64, error) {
+	return 0, errors.New("no elevation data")
+}
+
+// TestDensify checks the position, altitude and heading of inserted waypoints
+func TestDensify(t *testing.T) {
+	// A leg of about 1113 m east along the equator, climbing from 30 to 70 m
+	waypoints := route([2]float64{0, 0}, [2]float64{0, 0.01})
+	waypoints[0].Point.Altitude = 30
+	waypoints[1].Point.Altitude = 70
+
+	densified, err := fp2lm.Densify(waypoints, 300, nil)
+	if err != nil {
+		t.Fatalf("Densify returned error: %v", err)
+	}
+	if len(densified) != 5 {
+		t.Fatalf("expected 4 legs of about 278 m (5 waypoints), got %d waypoints", len(densified))
+	}
+	if densified[0] != waypoints[0] || densified[4] != waypoints[1] {
+		t.Error("expected the original waypoints to be kept at the ends")
+	}
+
+	for i, wp := range densified[1:4] {
+		k := float64(i + 1)
+		if math.Abs(wp.Point.Longitude-0.0025*k) > 1e-9 || math.Abs(wp.Point.Latitude) > 1e-9 {
+			t.Errorf("waypoint %d: expected 0, %.4f, got %.7f, %.7f", i+1, 0.0025*k, wp.Point.Latitude, wp.Point.Longitude)
+		}
+		if math.Abs(wp.Point.Altitude-(30+10*k)) > 1e-6 {
+			t.Errorf("waypoint %d: expected altitude %.1f, got %.3f", i+1, 30+10*k, wp.Point.Altitude)
+		}
+		if math.Abs(float64(wp.Heading)-90) > 1e-3 {
+			t.Errorf("waypoint %d: expected heading 90, got %.3f", i+1, wp.Heading)
+		}
+		if len(wp.Actions) != 0 {
+			t.Errorf("waypoint %d: expected no actions, got %v", i+1, wp.Actions)
+		}
+	}
+
+	// Short legs are left alone
+	if densified, _ := fp2lm.Densify(waypoints, 2000, nil); len(densified) != 2 {
+		t.Errorf("expected no waypoints inserted, got %d waypoints", len(densified))
+	}
+}
+
+// TestDensifyTerrain checks that inserted waypoints keep their height above the ground
+func TestDensifyTerrain(t *testing.T) {
+	waypoints := route([2]float64{0, 0}, [2]float64{0, 0.01})
+	waypoints[0].Point.Altitude = 30
+	waypoints[1].Point.Altitude = 30
+
+	// On a uniform slope the interpolated altitude already follows the ground
+	densified, err := fp2lm.Densify(waypoints, 300, slope{})
+	if err != nil {
+		t.Fatalf("Densify returned error: %v", err)
+	}
+	for i, wp := range densified[1:4] {
+		if math.Abs(wp.Point.Altitude-30) > 1e-6 {
+			t.Errorf("slope waypoint %d: expected altitude 30, got %.3f", i+1, wp.Point.Altitude)
+		}
+	}
+
+	// Over a hill the middle waypoint climbs with the ground
+	densified, err = fp2lm.Densify(waypoints, 300, hill{})
+	if err != nil {
+		t.Fatalf("Densify returned error: %v", err)
+	}
+	expected := []float64{30, 30, 50, 30, 30}
+	for i, wp := range densified {
+		if math.Abs(wp.Point.Altitude-expected[i]) > 1e-6 {
+			t.Errorf("hill waypoint %d: expected altitude %.1f, got %.3f", i, expected[i], wp.Point.Altitude)
+		}
+	}
+
+	if _, err := fp2lm.Densify(waypoints, 300, noData{}); err == nil {
+		t.Error("expected an error when the terrain model has no data")
+	}
+}
+
+// TestProcessWithDensify checks that Process breaks up legs longer than the spacing
+func TestProcessWithDensify(t *testing.T) {
+	options := fp2lm.DefaultOptions()
+	options.DensifySpacing = 50
+
+	var output strings.Builder
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options); err != nil {
+		t.Fatalf("Process returned error: %v", err)
+	}
+
+	// Four legs of about 81 m each become two
+	rows := outputRows(t, output.String())
+	if len(rows) != 9 {
+		t.Errorf("expected 9 waypoints, got %d", len(rows))
+	}
+}
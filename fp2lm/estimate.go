@@ -0,0 +1,209 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// actionDuration is the time allowed for a camera action such as taking a photo
+const actionDuration = time.Second
+
+// AircraftProfile describes the flight performance of an aircraft for estimates
+type AircraftProfile struct {
+	// Name identifies the aircraft
+	Name string
+
+	// CruiseSpeed is the horizontal speed in m/s used for waypoints with no speed set
+	CruiseSpeed float64
+
+	// ClimbSpeed and DescentSpeed are the largest vertical speeds in m/s
+	ClimbSpeed   float64
+	DescentSpeed float64
+
+	// Acceleration is the rate in m/s² at which the aircraft speeds up and slows down
+	// when stopping at a waypoint
+	Acceleration float64
+
+	// YawRate is the rotation speed in degrees per second used by rotate actions
+	YawRate float64
+
+	// Endurance is the usable flight time of one battery, keeping a reserve for landing
+	Endurance time.Duration
+}
+
+// aircraftProfiles holds the built-in profiles by lowercase name. Endurance is about
+// three quarters of the rated hover time, leaving a reserve for wind and landing.
+var aircraftProfiles = map[string]AircraftProfile{
+	"mini3pro": {
+		Name: "DJI Mini 3 Pro", CruiseSpeed: 8, ClimbSpeed: 5, DescentSpeed: 3.5,
+		Acceleration: 2.5, YawRate: 60, Endurance: 25 * time.Minute,
+	},
+	"air2s": {
+		Name: "DJI Air 2S", CruiseSpeed: 10, ClimbSpeed: 6, DescentSpeed: 6,
+		Acceleration: 3, YawRate: 60, Endurance: 23 * time.Minute,
+	},
+	"mavic2pro": {
+		Name: "DJI Mavic 2 Pro", CruiseSpeed: 10, ClimbSpeed: 5, DescentSpeed: 3,
+		Acceleration: 3, YawRate: 60, Endurance: 23 * time.Minute,
+	},
+	"mavic3": {
+		Name: "DJI Mavic 3", CruiseSpeed: 10, ClimbSpeed: 6, DescentSpeed: 6,
+		Acceleration: 3, YawRate: 60, Endurance: 34 * time.Minute,
+	},
+	"phantom4pro": {
+		Name: "DJI Phantom 4 Pro", CruiseSpeed: 10, ClimbSpeed: 6, DescentSpeed: 4,
+		Acceleration: 3, YawRate: 60, Endurance: 22 * time.Minute,
+	},
+}
+
+// AircraftNames returns the names of the built-in aircraft profiles in sorted order
+func AircraftNames() []string {
+	names := make([]string, 0, len(aircraftProfiles))
+	for name := range aircraftProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupAircraft returns the built-in aircraft profile with the given name, ignoring case
+func LookupAircraft(name string) (AircraftProfile, error) {
+	profile, ok := aircraftProfiles[strings.ToLower(name)]
+	if !ok {
+		return AircraftProfile{}, fmt.Errorf("unknown aircraft %q, expected one of %s",
+			name, strings.Join(AircraftNames(), ", "))
+	}
+	return profile, nil
+}
+
+// validate checks that the profile can be used for estimates
+func (p AircraftProfile) validate() error {
+	if p.CruiseSpeed <= 0 || p.ClimbSpeed <= 0 || p.DescentSpeed <= 0 {
+		return fmt.Errorf("aircraft speeds must be positive, got cruise %.1f, climb %.1f, descent %.1f m/s",
+			p.CruiseSpeed, p.ClimbSpeed, p.DescentSpeed)
+	}
+	if p.Acceleration <= 0 || p.YawRate <= 0 {
+		return fmt.Errorf("aircraft acceleration and yaw rate must be positive, got %.1f m/s² and %.1f deg/s",
+			p.Acceleration, p.YawRate)
+	}
+	if p.Endurance <= 0 {
+		return fmt.Errorf("aircraft endurance must be positive, got %s", p.Endurance)
+	}
+	return nil
+}
+
+// FlightEstimate summarizes the predicted flight time and battery use of a mission
+type FlightEstimate struct {
+	// Distance is the horizontal length of the route in meters
+	Distance float64
+
+	// FlightTime is the predicted time from the first waypoint to the last
+	FlightTime time.Duration
+
+	// BatteryUse is the flight time as a share of one battery's endurance
+	BatteryUse float64
+
+	// Batteries is the number of batteries needed to fly the mission
+	Batteries int
+}
+
+// EstimateFlight predicts how long a mission will take to fly and how many batteries
+// it will use
+//
+// Parameters:
+//   - waypoints: The converted mission waypoints in flight order
+//   - profile: The performance of the aircraft flying the mission
+//
+// Each leg is flown at the speed of the waypoint that starts it, or the profile's
+// cruise speed when none is set, and takes as long as the slower of its horizontal
+// and vertical movement. The aircraft stops at the first and last waypoints and at
+// any waypoint without a curve size or with actions, adding the time to slow down
+// and speed up again. Stay actions add their duration, rotate actions the time to
+// turn at the profile's yaw rate, and other actions one second each.
+func EstimateFlight(waypoints []*missioncsv.LitchiWaypoint, profile AircraftProfile) (FlightEstimate, error) {
+	if err := profile.validate(); err != nil {
+		return FlightEstimate{}, err
+	}
+
+	seconds, distance := flightSeconds(waypoints, profile)
+	estimate := FlightEstimate{
+		Distance:   distance,
+		FlightTime: time.Duration(seconds * float64(time.Second)).Round(time.Second),
+		BatteryUse: seconds / profile.Endurance.Seconds(),
+	}
+	estimate.Batteries = int(math.Ceil(estimate.BatteryUse))
+	return estimate, nil
+}
+
+// flightSeconds returns the predicted time in seconds to fly a mission from its first
+// waypoint to its last, and the horizontal distance covered in meters
+func flightSeconds(waypoints []*missioncsv.LitchiWaypoint, profile AircraftProfile) (seconds, distance float64) {
+	for i, wp := range waypoints {
+		seconds += actionsDuration(wp, profile).Seconds()
+		if i == len(waypoints)-1 {
+			break
+		}
+
+		next := waypoints[i+1]
+		leg := Distance(wp.Point.Latitude, wp.Point.Longitude, next.Point.Latitude, next.Point.Longitude)
+		distance += leg
+		seconds += legDuration(wp, next, leg, i == 0, i+1 == len(waypoints)-1, profile).Seconds()
+	}
+	return seconds, distance
+}
+
+// legDuration returns the time to fly from one waypoint to the next, a horizontal
+// distance apart. first and last mark legs starting or ending at the ends of the route.
+func legDuration(from, to *missioncsv.LitchiWaypoint, distance float64, first, last bool, profile AircraftProfile) time.Duration {
+	speed := float64(from.Speed)
+	if speed <= 0 {
+		speed = profile.CruiseSpeed
+	}
+	seconds := distance / speed
+
+	climb := to.Point.Altitude - from.Point.Altitude
+	if climb > 0 {
+		seconds = math.Max(seconds, climb/profile.ClimbSpeed)
+	} else {
+		seconds = math.Max(seconds, -climb/profile.DescentSpeed)
+	}
+
+	// Speeding up from or slowing down to a stop takes speed / (2 * acceleration)
+	// longer than covering the same distance at full speed
+	stopPenalty := speed / (2 * profile.Acceleration)
+	if first || stopsAt(from) {
+		seconds += stopPenalty
+	}
+	if last || stopsAt(to) {
+		seconds += stopPenalty
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// stopsAt reports whether the aircraft stops at a waypoint instead of curving through it
+func stopsAt(wp *missioncsv.LitchiWaypoint) bool {
+	return wp.CurveSize == 0 || len(wp.Actions) > 0
+}
+
+// actionsDuration returns the time spent performing a waypoint's actions
+func actionsDuration(wp *missioncsv.LitchiWaypoint, profile AircraftProfile) time.Duration {
+	var total time.Duration
+	heading := float64(wp.Heading)
+	for _, action := range wp.Actions {
+		switch action.Type {
+		case missioncsv.ActionStay:
+			total += time.Duration(action.Param) * time.Millisecond
+		case missioncsv.ActionRotateAircraft:
+			turn := turnAngle(heading, float64(action.Param))
+			total += time.Duration(turn / profile.YawRate * float64(time.Second))
+			heading = float64(action.Param)
+		default:
+			total += actionDuration
+		}
+	}
+	return total
+}
@@ -0,0 +1,107 @@
+package fp2lm_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testAircraft is a profile with round numbers: stopping or starting costs 2.5 s
+var testAircraft = fp2lm.AircraftProfile{
+	Name: "Test", CruiseSpeed: 10, ClimbSpeed: 2, DescentSpeed: 1,
+	Acceleration: 2, YawRate: 45, Endurance: 100 * time.Second,
+}
+
+// TestEstimateFlight checks leg, stop and action times on a climbing route
+func TestEstimateFlight(t *testing.T) {
+	waypoints := route([2]float64{0, 0}, [2]float64{0, 0.009}, [2]float64{0, 0.018})
+	waypoints[0].Point.Altitude = 30
+	waypoints[1].Point.Altitude = 30
+	waypoints[2].Point.Altitude = 330
+	waypoints[1].Heading = 0
+	waypoints[1].Actions = append(waypoints[1].Actions,
+		missioncsv.Action{Type: missioncsv.ActionStay, Param: 2000},
+		missioncsv.Action{Type: missioncsv.ActionRotateAircraft, Param: 90},
+	)
+
+	estimate, err := fp2lm.EstimateFlight(waypoints, testAircraft)
+	if err != nil {
+		t.Fatalf("EstimateFlight returned error: %v", err)
+	}
+
+	leg := fp2lm.Distance(0, 0, 0, 0.009)
+	expected := leg/10 + 2*2.5 + // level leg flown at cruise speed, stopping at both ends
+		300/2 + 2*2.5 + // climb-limited leg
+		3 + // a photo at every waypoint
+		2 + 90/45 // hover and rotate
+	if math.Abs(estimate.FlightTime.Seconds()-expected) > 0.5 {
+		t.Errorf("expected flight time %.1f s, got %s", expected, estimate.FlightTime)
+	}
+	if math.Abs(estimate.Distance-2*leg) > 0.01 {
+		t.Errorf("expected distance %.1f m, got %.1f m", 2*leg, estimate.Distance)
+	}
+	if estimate.Batteries != 3 {
+		t.Errorf("expected 3 batteries, got %d", estimate.Batteries)
+	}
+}
+
+// TestEstimateFlightCurves checks that the aircraft keeps its speed through curves
+func TestEstimateFlightCurves(t *testing.T) {
+	waypoints := route([2]float64{0, 0}, [2]float64{0, 0.009}, [2]float64{0, 0.018})
+	for _, wp := range waypoints {
+		wp.Actions = nil
+		wp.Speed = 5
+	}
+	waypoints[1].CurveSize = 20
+
+	estimate, err := fp2lm.EstimateFlight(waypoints, testAircraft)
+	if err != nil {
+		t.Fatalf("EstimateFlight returned error: %v", err)
+	}
+
+	// At 5 m/s starting and stopping each cost 1.25 s
+	expected := 2*fp2lm.Distance(0, 0, 0, 0.009)/5 + 2*1.25
+	if math.Abs(estimate.FlightTime.Seconds()-expected) > 0.5 {
+		t.Errorf("expected flight time %.1f s, got %s", expected, estimate.FlightTime)
+	}
+}
+
+// TestLookupAircraft checks that built-in profiles are found by name
+func TestLookupAircraft(t *testing.T) {
+	for _, name := range fp2lm.AircraftNames() {
+		profile, err := fp2lm.LookupAircraft(strings.ToUpper(name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if _, err := fp2lm.EstimateFlight(lShapedRoute(), profile); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if _, err := fp2lm.LookupAircraft("zeppelin"); err == nil {
+		t.Error("expected an error for an unknown aircraft")
+	}
+	if _, err := fp2lm.EstimateFlight(lShapedRoute(), fp2lm.AircraftProfile{}); err == nil {
+		t.Error("expected an error for an empty profile")
+	}
+}
+
+// TestProcessWithAircraft checks that Process validates the aircraft name
+func TestProcessWithAircraft(t *testing.T) {
+	options := fp2lm.DefaultOptions()
+	options.Aircraft = "mavic3"
+
+	var output strings.Builder
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options); err != nil {
+		t.Fatalf("Process returned error: %v", err)
+	}
+
+	options.Aircraft = "zeppelin"
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options); err == nil {
+		t.Error("expected an error for an unknown aircraft")
+	}
+}
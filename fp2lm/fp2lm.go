@@ -96,22 +96,143 @@ type ConverterOptions struct {
 
 	// GimbalPitch specifies the camera angle in degrees (between -90 and 0)
 	GimbalPitch float64
+
+	// POI optionally specifies a point of interest. When set, every waypoint's gimbal
+	// pitch is aimed at it and the POI fields are filled in. The POI altitude is
+	// interpreted in AltitudeMode; waypoints that fell back to absolute altitudes are
+	// aimed using the takeoff elevation, which needs Home or Terrain.
+	POI *missioncsv.POI
+
+	// Heading selects how waypoint headings are computed. An empty mode faces the
+	// point of interest when one is set, and the next waypoint otherwise.
+	Heading HeadingStrategy
+
+	// PitchKeyframes optionally ramps the gimbal pitch along the mission, overriding
+	// GimbalPitch and any pitch aimed at the point of interest
+	PitchKeyframes []PitchKeyframe
+
+	// PitchKeyframeUnit determines how keyframe positions are interpreted:
+	// "index" (default) uses waypoint indices, "distance" uses meters along the route.
+	// Indices count the waypoints as planned, before simplifying or densifying.
+	PitchKeyframeUnit string
+
+	// ActionTemplate optionally replaces the default take-photo action at every
+	// waypoint with the actions it selects. See ActionTemplate for the syntax. Rules
+	// select from the waypoints as planned, before simplifying or densifying.
+	ActionTemplate string
+
+	// Camera optionally describes the camera's shooting limits. When set, each
+	// waypoint's speed is planned from its photo interval with PlanSpeed instead of
+	// using Litchi's cruise speed.
+	Camera *Camera
+
+	// Curves optionally computes a curve size for each waypoint so the aircraft flies
+	// smooth turns instead of stopping. See CurvePlan.Apply.
+	Curves *CurvePlan
+
+	// SimplifyTolerance optionally removes redundant waypoints lying within this many
+	// meters of the simplified path before headings are computed. See Simplify.
+	SimplifyTolerance float64
+
+	// DensifySpacing optionally inserts intermediate waypoints so no leg is longer
+	// than this many meters. See Densify.
+	DensifySpacing float64
+
+	// Terrain optionally provides ground elevations so that waypoints inserted by
+	// DensifySpacing follow the terrain instead of a straight climb or descent. It is
+	// also used for the takeoff elevation when checking clearance above Surface.
+	Terrain ElevationModel
+
+	// Aircraft optionally names a built-in aircraft profile, such as "mavic3", used to
+	// estimate flight time and battery use after conversion. See AircraftNames.
+	Aircraft string
+
+	// Zones optionally lists exclusion and inclusion zones that every waypoint and leg
+	// is checked against after conversion. See CheckGeofence and LoadZones.
+	Zones []Zone
+
+	// GeofenceAction determines what happens when the mission breaks a zone:
+	// "fail" (default) stops the conversion, "warn" logs each violation and continues
+	GeofenceAction string
+
+	// Home optionally sets the takeoff point. When set, the farthest waypoint from
+	// home is logged and MaxDistance is enforced. Convert uses only its coordinates;
+	// its altitude is the one SplitByBattery expects, in the waypoints' altitude mode.
+	Home *missioncsv.Point
+
+	// MaxDistance optionally limits the horizontal distance in meters of every
+	// waypoint and leg from Home, for example to keep the aircraft within visual line
+	// of sight. 0 means no limit.
+	MaxDistance float64
+
+	// Surface optionally provides surface elevations, including trees and buildings,
+	// for checking obstacle clearance along every leg. See CheckClearance. Relative
+	// altitudes are measured from the takeoff elevation, which needs Home or Terrain;
+	// see TakeoffElevation.
+	Surface ElevationModel
+
+	// MinClearance is the smallest allowed height in meters above Surface
+	MinClearance float64
+
+	// ClearanceStep is the distance in meters between surface samples along each leg.
+	// 0 uses defaultClearanceStep.
+	ClearanceStep float64
+
+	// ClearanceAction determines what happens when the route passes too close to
+	// Surface: "fail" (default) stops the conversion, "warn" logs each violation and
+	// continues
+	ClearanceAction string
 }
 
+// defaultClearanceStep is the sample spacing in meters used when ClearanceStep is 0
+const defaultClearanceStep = 5
+
 // DefaultOptions returns recommended default options for the converter
 //
 // The default options are:
 // - AltitudeMode: "agl" (relative altitudes)
 // - PhotoInterval: 0 (no interval set)
 // - GimbalPitch: -90 degrees (straight down)
+// - POI: nil (no point of interest)
+// - Heading: empty mode (face the next waypoint, or the POI when one is set)
+// - PitchKeyframes: none, PitchKeyframeUnit: "index"
+// - ActionTemplate: "" (take a photo at every waypoint)
+// - Camera: nil (fly at Litchi's cruise speed)
+// - Curves: nil (stop at every waypoint)
+// - SimplifyTolerance: 0 (keep every waypoint)
+// - DensifySpacing: 0 (keep legs as planned), Terrain: nil
+// - Aircraft: "" (no flight estimate)
+// - Zones: none, GeofenceAction: "fail"
+// - Home: nil, MaxDistance: 0 (no limit)
+// - Surface: nil (no clearance check), MinClearance: 0, ClearanceStep: 5 meters
+// - ClearanceAction: "fail"
 //
 // Note: No altitude safety limits are enforced - pilots are responsible
 // for ensuring compliance with local regulations and safe operating practices.
 func DefaultOptions() *ConverterOptions {
 	return &ConverterOptions{
-		AltitudeMode:  "agl",
-		PhotoInterval: 0,
-		GimbalPitch:   -90,
+		AltitudeMode:      "agl",
+		PhotoInterval:     0,
+		GimbalPitch:       -90,
+		POI:               nil,
+		Heading:           HeadingStrategy{},
+		PitchKeyframes:    nil,
+		PitchKeyframeUnit: "index",
+		ActionTemplate:    "",
+		Camera:            nil,
+		Curves:            nil,
+		SimplifyTolerance: 0,
+		DensifySpacing:    0,
+		Terrain:           nil,
+		Aircraft:          "",
+		Zones:             nil,
+		GeofenceAction:    "fail",
+		Home:              nil,
+		MaxDistance:       0,
+		Surface:           nil,
+		MinClearance:      0,
+		ClearanceStep:     defaultClearanceStep,
+		ClearanceAction:   "fail",
 	}
 }
 
@@ -122,12 +243,40 @@ func DefaultOptions() *ConverterOptions {
 //   - output: Writer where the Litchi mission CSV will be written
 //   - options: Configuration options for the conversion
 //
+// Process is Convert followed by WriteMission.
+func Process(input io.Reader, output io.Writer, options *ConverterOptions) error {
+	waypoints, err := Convert(input, options)
+	if err != nil {
+		return err
+	}
+	return WriteMission(output, waypoints)
+}
+
+// Convert reads Flight Planner CSV data and returns the Litchi mission waypoints
+//
+// Parameters:
+//   - input: Reader providing the source Flight Planner CSV data
+//   - options: Configuration options for the conversion
+//
 // The function handles:
 //   - Parsing of the input CSV
 //   - Conversion of coordinates and altitude data
-//   - Calculation of bearings between waypoints
-//   - Formatting and output of the Litchi mission
-func Process(input io.Reader, output io.Writer, options *ConverterOptions) error {
+//   - Expanding the action template, if one is set
+//   - Removal of redundant waypoints, if a simplification tolerance is set
+//   - Insertion of intermediate waypoints on long legs, if a densify spacing is set
+//   - Calculation of headings using the selected heading strategy
+//   - Aiming the gimbal at the point of interest, if one is set
+//   - Interpolating gimbal pitch between keyframes, if any are set
+//   - Planning waypoint speeds from the camera limits, if they are set
+//   - Computing curve sizes for smooth turns, if a curve plan is set
+//   - Checking every waypoint and leg against the geofence zones, if any are set
+//   - Checking the distance of every waypoint and leg from home, if a home point is set
+//   - Logging the length of each leg and of the whole route
+//   - Estimating flight time and battery use, if an aircraft is set
+//
+// Action template selectors and keyframe indices refer to the waypoints as planned,
+// before any are removed or inserted.
+func Convert(input io.Reader, options *ConverterOptions) ([]*missioncsv.LitchiWaypoint, error) {
 	if options == nil {
 		options = DefaultOptions()
 	}
@@ -135,26 +284,129 @@ func Process(input io.Reader, output io.Writer, options *ConverterOptions) error
 	// Validate altitude mode
 	altitudeModeStr := strings.ToLower(options.AltitudeMode)
 	if altitudeModeStr != "asl" && altitudeModeStr != "agl" {
-		return fmt.Errorf("altitude mode must be either 'asl' or 'agl', got %q", options.AltitudeMode)
+		return nil, fmt.Errorf("altitude mode must be either 'asl' or 'agl', got %q", options.AltitudeMode)
 	}
 
 	// Validate pitch value
 	if options.GimbalPitch < -90 || options.GimbalPitch > 0 {
-		return fmt.Errorf("gimbal pitch must be between -90 and 0 degrees, got %.1f", options.GimbalPitch)
+		return nil, fmt.Errorf("gimbal pitch must be between -90 and 0 degrees, got %.1f", options.GimbalPitch)
 	}
 
-	scanner := bufio.NewScanner(input)
-	waypoints := []*missioncsv.LitchiWaypoint{}
+	// Validate point of interest
+	if options.POI != nil {
+		if math.IsNaN(options.POI.Latitude) || math.IsNaN(options.POI.Longitude) ||
+			options.POI.Latitude < -90 || options.POI.Latitude > 90 ||
+			options.POI.Longitude < -180 || options.POI.Longitude > 180 {
+			return nil, fmt.Errorf("point of interest must be a valid coordinate, got %.7f, %.7f",
+				options.POI.Latitude, options.POI.Longitude)
+		}
+		if math.IsNaN(options.POI.Altitude) || math.IsInf(options.POI.Altitude, 0) {
+			return nil, fmt.Errorf("point of interest altitude must be a number, got %.1f", options.POI.Altitude)
+		}
+	}
 
-	// Create a CSV writer for the output
-	missionWriter := missioncsv.NewWriter(output)
+	// Validate heading strategy, facing the point of interest by default when one is set
+	heading := options.Heading
+	if heading.Mode == "" && options.POI != nil {
+		heading.Mode = "poi"
+	}
+	if err := heading.Validate(options.POI); err != nil {
+		return nil, err
+	}
 
-	// Write the Litchi Mission header
-	err := missionWriter.WriteLitchiHeader()
-	if err != nil {
-		return fmt.Errorf("failed to write header: %w", err)
+	// Validate pitch keyframes
+	if err := validatePitchKeyframes(options.PitchKeyframes, options.PitchKeyframeUnit); err != nil {
+		return nil, err
+	}
+
+	// Parse action template
+	var actionTemplate *ActionTemplate
+	if strings.TrimSpace(options.ActionTemplate) != "" {
+		parsed, err := ParseActionTemplate(options.ActionTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid action template: %w", err)
+		}
+		actionTemplate = parsed
+	}
+
+	// Validate camera limits
+	if options.Camera != nil {
+		if err := options.Camera.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate curve plan
+	if options.Curves != nil {
+		if err := options.Curves.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate simplification tolerance
+	if options.SimplifyTolerance < 0 {
+		return nil, fmt.Errorf("simplification tolerance must not be negative, got %.1f", options.SimplifyTolerance)
+	}
+
+	// Validate densify spacing
+	if options.DensifySpacing < 0 {
+		return nil, fmt.Errorf("densify spacing must not be negative, got %.1f", options.DensifySpacing)
+	}
+
+	// Look up the aircraft profile for the flight estimate
+	var aircraft *AircraftProfile
+	if options.Aircraft != "" {
+		profile, err := LookupAircraft(options.Aircraft)
+		if err != nil {
+			return nil, err
+		}
+		aircraft = &profile
+	}
+
+	// Validate geofence action
+	geofenceAction := strings.ToLower(options.GeofenceAction)
+	if geofenceAction != "" && geofenceAction != "fail" && geofenceAction != "warn" {
+		return nil, fmt.Errorf("geofence action must be either 'fail' or 'warn', got %q", options.GeofenceAction)
+	}
+
+	// Validate home point and distance limit
+	if options.Home != nil {
+		if math.IsNaN(options.Home.Latitude) || math.IsNaN(options.Home.Longitude) ||
+			options.Home.Latitude < -90 || options.Home.Latitude > 90 ||
+			options.Home.Longitude < -180 || options.Home.Longitude > 180 {
+			return nil, fmt.Errorf("home point must be a valid coordinate, got %.7f, %.7f",
+				options.Home.Latitude, options.Home.Longitude)
+		}
+		if math.IsNaN(options.Home.Altitude) || math.IsInf(options.Home.Altitude, 0) {
+			return nil, fmt.Errorf("home point altitude must be a number, got %.1f", options.Home.Altitude)
+		}
+	}
+	if options.MaxDistance < 0 {
+		return nil, fmt.Errorf("maximum distance from home must not be negative, got %.1f", options.MaxDistance)
+	}
+	if options.MaxDistance > 0 && options.Home == nil {
+		return nil, fmt.Errorf("maximum distance from home requires a home point")
 	}
 
+	// Validate clearance settings
+	if options.MinClearance < 0 {
+		return nil, fmt.Errorf("minimum clearance must not be negative, got %.1f", options.MinClearance)
+	}
+	if options.ClearanceStep < 0 {
+		return nil, fmt.Errorf("clearance step must not be negative, got %.1f", options.ClearanceStep)
+	}
+	clearanceStep := options.ClearanceStep
+	if clearanceStep == 0 {
+		clearanceStep = defaultClearanceStep
+	}
+	clearanceAction := strings.ToLower(options.ClearanceAction)
+	if clearanceAction != "" && clearanceAction != "fail" && clearanceAction != "warn" {
+		return nil, fmt.Errorf("clearance action must be either 'fail' or 'warn', got %q", options.ClearanceAction)
+	}
+
+	scanner := bufio.NewScanner(input)
+	waypoints := []*missioncsv.LitchiWaypoint{}
+
 	lineNum := 0
 	for scanner.Scan() {
 		lineNum++
@@ -238,32 +490,162 @@ func Process(input io.Reader, output io.Writer, options *ConverterOptions) error
 
 		// Add a default photo action
 		wp.Actions = []missioncsv.Action{
-			{Type: 1, Param: 0}, // Take photo
+			{Type: missioncsv.ActionTakePhoto, Param: 0},
 		}
 
 		waypoints = append(waypoints, wp)
 	}
 
-	// Calculate headings for all waypoints
-	if len(waypoints) > 1 {
-		// For the first waypoint, calculate heading based on the second waypoint
-		firstWp := waypoints[0]
-		secondWp := waypoints[1]
-		firstWp.Heading = float32(CalculateBearing(firstWp.Point.Latitude, firstWp.Point.Longitude,
-			secondWp.Point.Latitude, secondWp.Point.Longitude))
+	// Replace the default actions with those from the template, and pin index
+	// keyframes to the route, while waypoints are numbered as planned
+	var rotations headingRotations
+	if actionTemplate != nil {
+		var err error
+		if rotations, err = actionTemplate.expand(waypoints); err != nil {
+			return nil, err
+		}
+	}
+	keyframes, keyframeUnit := options.PitchKeyframes, options.PitchKeyframeUnit
+	if strings.ToLower(keyframeUnit) != "distance" {
+		keyframes, keyframeUnit = keyframesByDistance(waypoints, keyframes), "distance"
+	}
+
+	// Drop redundant waypoints on straight segments
+	if options.SimplifyTolerance > 0 {
+		var removed int
+		waypoints, removed = Simplify(waypoints, options.SimplifyTolerance)
+		slog.Info("Simplified mission", "removed", removed, "remaining", len(waypoints))
+	}
+
+	// Break up long legs
+	if options.DensifySpacing > 0 {
+		densified, err := Densify(waypoints, options.DensifySpacing, options.Terrain)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Densified mission", "added", len(densified)-len(waypoints), "total", len(densified))
+		waypoints = densified
+	}
+
+	// Calculate headings for all waypoints, then rotate to them where the template asks
+	if err := heading.Apply(waypoints, options.POI); err != nil {
+		return nil, err
+	}
+	rotations.apply()
+
+	// Aim the gimbal at the point of interest
+	if options.POI != nil {
+		// Waypoints that fell back to absolute altitudes need the takeoff elevation to
+		// be compared with the POI
+		poiAltMode := int8(1) // Relative (AGL)
+		if altitudeModeStr == "asl" {
+			poiAltMode = 0 // Absolute
+		}
+		var takeoffElevation float64
+		if mixedAltitudeModes(waypoints, poiAltMode) {
+			var err error
+			takeoffElevation, err = findTakeoffElevation(waypoints, options.Home, options.Terrain, options.Surface)
+			if err != nil {
+				return nil, fmt.Errorf("point of interest with mixed altitude modes: %w", err)
+			}
+		}
+		applyPOI(waypoints, *options.POI, poiAltMode, takeoffElevation)
+	}
+
+	// Ramp the gimbal pitch between keyframes
+	applyPitchKeyframes(waypoints, keyframes, keyframeUnit)
+
+	// Plan speeds so the camera can keep up with the photo interval
+	if options.Camera != nil {
+		applySpeedPlan(waypoints, *options.Camera)
+	}
 
-		// For remaining waypoints, calculate heading based on the next waypoint
-		for i := 1; i < len(waypoints)-1; i++ {
-			currentWp := waypoints[i]
-			nextWp := waypoints[i+1]
-			currentWp.Heading = float32(CalculateBearing(currentWp.Point.Latitude, currentWp.Point.Longitude,
-				nextWp.Point.Latitude, nextWp.Point.Longitude))
+	// Smooth the turns between legs
+	if options.Curves != nil {
+		if err := options.Curves.Apply(waypoints); err != nil {
+			return nil, err
 		}
+	}
 
-		// For the last waypoint, use the same heading as the previous waypoint
-		lastWp := waypoints[len(waypoints)-1]
-		secondLastWp := waypoints[len(waypoints)-2]
-		lastWp.Heading = secondLastWp.Heading
+	// Check the mission against the geofence
+	if violations := CheckGeofence(waypoints, options.Zones); len(violations) > 0 {
+		if geofenceAction == "warn" {
+			for _, violation := range violations {
+				slog.Warn("Geofence violation", "violation", violation.String())
+			}
+		} else {
+			messages := make([]string, len(violations))
+			for i, violation := range violations {
+				messages[i] = violation.String()
+			}
+			return nil, fmt.Errorf("mission violates the geofence: %s", strings.Join(messages, "; "))
+		}
+	}
+
+	// Check the distance from home
+	if options.Home != nil && len(waypoints) > 0 {
+		report := CheckRange(waypoints, *options.Home, options.MaxDistance)
+		slog.Info("Farthest waypoint from home",
+			"waypoint", report.Farthest+1,
+			"meters", math.Round(report.FarthestDistance*10)/10)
+		if len(report.Outside) > 0 {
+			return nil, rangeError(report, options.MaxDistance)
+		}
+	}
+
+	// Check clearance above the surface, with relative altitudes measured from the
+	// elevation of the takeoff point
+	if options.Surface != nil && len(waypoints) > 0 {
+		takeoffElevation, err := TakeoffElevation(waypoints, options.Home, options.Terrain, options.Surface)
+		if err != nil {
+			return nil, err
+		}
+		violations, err := CheckClearance(waypoints, options.Surface, takeoffElevation, options.MinClearance, clearanceStep)
+		if err != nil {
+			return nil, err
+		}
+		if len(violations) > 0 {
+			for _, violation := range violations {
+				slog.Warn("Insufficient clearance", "violation", violation.String())
+			}
+			if clearanceAction != "warn" {
+				return nil, clearanceError(violations, options.MinClearance)
+			}
+		}
+	}
+
+	// Report leg and route lengths
+	logRouteMetrics(MeasureRoute(waypoints))
+
+	// Estimate flight time and batteries
+	if aircraft != nil {
+		estimate, err := EstimateFlight(waypoints, *aircraft)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Flight estimate",
+			"aircraft", aircraft.Name,
+			"flightTime", estimate.FlightTime.String(),
+			"batteries", estimate.Batteries)
+		if estimate.BatteryUse > 1 {
+			slog.Warn("Mission exceeds a single battery",
+				"flightTime", estimate.FlightTime.String(),
+				"endurance", aircraft.Endurance.String(),
+				"batteryUse", math.Round(estimate.BatteryUse*100)/100)
+		}
+	}
+
+	return waypoints, nil
+}
+
+// WriteMission writes waypoints to output as a Litchi mission CSV, header first
+func WriteMission(output io.Writer, waypoints []*missioncsv.LitchiWaypoint) error {
+	// Create a CSV writer for the output
+	missionWriter := missioncsv.NewWriter(output)
+
+	// Write the Litchi Mission header
+	if err := missionWriter.WriteLitchiHeader(); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
 	}
 
 	// Write all waypoints
@@ -279,7 +661,6 @@ func Process(input io.Reader, output io.Writer, options *ConverterOptions) error
 	if err := missionWriter.Error(); err != nil {
 		return fmt.Errorf("error writing CSV output: %w", err)
 	}
-
 	return nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	_ "embed"
 	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
 	"math"
 	"strings"
 	"testing"
@@ -280,3 +281,124 @@ func TestProcessWithMissingFields(t *testing.T) {
 		t.Errorf("expected only header line, got %d lines", len(lines))
 	}
 }
+
+// outputRows returns the data rows of a Litchi CSV, split into fields
+func outputRows(t *testing.T, output string) [][]string {
+	t.Helper()
+	lines := strings.Split(canonicalizeCSV(output), "\n")
+	if len(lines) < 1 || !strings.HasPrefix(lines[0], "latitude,") {
+		t.Fatalf("missing Litchi header in output")
+	}
+	var rows [][]string
+	for _, line := range lines[1:] {
+		rows = append(rows, strings.Split(line, ","))
+	}
+	return rows
+}
+
+// TestProcessWithPOI checks that waypoints face and aim at the point of interest
+func TestProcessWithPOI(t *testing.T) {
+	input := "Waypoint Number,X [m],Y [m],Alt. ASL [m],Alt. AGL [m],xcoord,ycoord\n" +
+		"1,0,0,60,40,-89.0010000,43.0000000\n" + // ~81 m west of the POI
+		"2,0,0,60,40,-89.0000000,43.0010000\n" // ~111 m north of the POI
+
+	options := fp2lm.DefaultOptions()
+	options.POI = &missioncsv.POI{Latitude: 43.0, Longitude: -89.0, Altitude: 0}
+
+	var out bytes.Buffer
+	if err := fp2lm.Process(strings.NewReader(input), &out, options); err != nil {
+		t.Fatalf("Process returned error: %v", err)
+	}
+
+	rows := outputRows(t, out.String())
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 waypoints, got %d", len(rows))
+	}
+
+	tests := []struct {
+		heading, pitch string
+	}{
+		{"90.0", "-26.1"},  // atan(40 / 81.5)
+		{"180.0", "-19.8"}, // atan(40 / 111.1)
+	}
+	for i, tt := range tests {
+		row := rows[i]
+		if row[3] != tt.heading {
+			t.Errorf("waypoint %d: expected heading %s, got %s", i, tt.heading, row[3])
+		}
+		if row[6] != "1" {
+			t.Errorf("waypoint %d: expected gimbal mode 1, got %s", i, row[6])
+		}
+		if row[7] != tt.pitch {
+			t.Errorf("waypoint %d: expected pitch %s, got %s", i, tt.pitch, row[7])
+		}
+		if row[40] != "43.0000000" || row[41] != "-89.0000000" || row[43] != "1" {
+			t.Errorf("waypoint %d: expected POI fields to be set, got %v", i, row[40:44])
+		}
+	}
+}
+
+// TestProcessWithPOIMixedModes checks that a waypoint that fell back to an absolute
+// altitude is aimed at a relative POI through the takeoff elevation
+func TestProcessWithPOIMixedModes(t *testing.T) {
+	input := "Waypoint Number,X [m],Y [m],Alt. ASL [m],Alt. AGL [m],xcoord,ycoord\n" +
+		"1,0,0,140,40,-89.0010000,43.0000000\n" + // ~81 m west of the POI
+		"2,0,0,60,nan,-89.0000000,43.0010000\n" // ~111 m north of the POI, 40 m below takeoff
+
+	options := fp2lm.DefaultOptions()
+	options.POI = &missioncsv.POI{Latitude: 43.0, Longitude: -89.0, Altitude: 0}
+
+	var out bytes.Buffer
+	if err := fp2lm.Process(strings.NewReader(input), &out, options); err == nil {
+		t.Error("expected an error without a takeoff elevation")
+	}
+
+	// Taking off on the 100 m plain puts the POI at 100 m above sea level
+	options.Terrain = hill{}
+	out.Reset()
+	if err := fp2lm.Process(strings.NewReader(input), &out, options); err != nil {
+		t.Fatalf("Process returned error: %v", err)
+	}
+	rows := outputRows(t, out.String())
+	for i, pitch := range []string{"-26.1", "19.8"} {
+		if rows[i][7] != pitch {
+			t.Errorf("waypoint %d: expected pitch %s, got %s", i, pitch, rows[i][7])
+		}
+		if rows[i][43] != "1" {
+			t.Errorf("waypoint %d: expected the POI to stay relative, got mode %s", i, rows[i][43])
+		}
+	}
+}
+
+// TestProcessWithInvalidPOI checks that a point of interest that is not a number is rejected
+func TestProcessWithInvalidPOI(t *testing.T) {
+	for _, poi := range []missioncsv.POI{
+		{Latitude: math.NaN(), Longitude: -89},
+		{Latitude: 43, Longitude: math.NaN()},
+		{Latitude: 43, Longitude: -89, Altitude: math.NaN()},
+	} {
+		options := fp2lm.DefaultOptions()
+		options.POI = &poi
+		var out bytes.Buffer
+		if err := fp2lm.Process(strings.NewReader(eastboundInput), &out, options); err == nil {
+			t.Errorf("expected an error for POI %+v", poi)
+		}
+	}
+}
+
+// TestProcessWithInvalidHome checks that NaN and infinite home points are rejected
+func TestProcessWithInvalidHome(t *testing.T) {
+	for _, home := range []missioncsv.Point{
+		{Latitude: math.NaN(), Longitude: -89},
+		{Latitude: 43, Longitude: math.NaN()},
+		{Latitude: 43, Longitude: -89, Altitude: math.NaN()},
+		{Latitude: 43, Longitude: -89, Altitude: math.Inf(1)},
+	} {
+		options := fp2lm.DefaultOptions()
+		options.Home = &home
+		var out bytes.Buffer
+		if err := fp2lm.Process(strings.NewReader(eastboundInput), &out, options); err == nil {
+			t.Errorf("expected an error for home %+v", home)
+		}
+	}
+}
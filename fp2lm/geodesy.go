@@ -0,0 +1,260 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"log/slog"
+	"math"
+)
+
+// WGS84 ellipsoid parameters
+const (
+	wgs84A = 6378137.0
+	wgs84F = 1 / 298.257223563
+	wgs84B = (1 - wgs84F) * wgs84A
+)
+
+// vincentyTolerance is the change in radians at which Vincenty's iterations stop,
+// about 0.006 mm on the ground
+const vincentyTolerance = 1e-12
+
+// vincentyIterations bounds the iterations of Vincenty's inverse formula, which does
+// not converge for nearly antipodal points
+const vincentyIterations = 200
+
+// Distance computes the geodesic distance in meters between two points on the WGS84
+// ellipsoid using Vincenty's inverse formula
+//
+// Parameters:
+//   - lat1, lon1: Coordinates of the first point in decimal degrees
+//   - lat2, lon2: Coordinates of the second point in decimal degrees
+//
+// Returns:
+//   - The distance in meters, accurate to well under a millimeter
+//
+// For nearly antipodal points, where Vincenty's formula does not converge, the
+// great-circle distance on a sphere of the mean Earth radius is returned instead.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	distance, _, ok := vincentyInverse(lat1, lon1, lat2, lon2)
+	if !ok {
+		return haversineDistance(lat1, lon1, lat2, lon2)
+	}
+	return distance
+}
+
+// Destination computes the point reached by travelling a distance along the geodesic
+// leaving a point at the given initial bearing, using Vincenty's direct formula
+//
+// Parameters:
+//   - lat, lon: Coordinates of the starting point in decimal degrees
+//   - bearing: The initial bearing in degrees from North
+//   - distance: The distance to travel in meters
+//
+// Returns:
+//   - The latitude and longitude of the destination in decimal degrees, with the
+//     longitude in the range [-180, 180)
+func Destination(lat, lon, bearing, distance float64) (float64, float64) {
+	alpha1 := bearing * math.Pi / 180
+	sinAlpha1, cosAlpha1 := math.Sin(alpha1), math.Cos(alpha1)
+
+	tanU1 := (1 - wgs84F) * math.Tan(lat*math.Pi/180)
+	cosU1 := 1 / math.Sqrt(1+tanU1*tanU1)
+	sinU1 := tanU1 * cosU1
+
+	sigma1 := math.Atan2(tanU1, cosAlpha1)
+	sinAlpha := cosU1 * sinAlpha1
+	cosSqAlpha := 1 - sinAlpha*sinAlpha
+	a, b := vincentyCoefficients(cosSqAlpha)
+
+	sigma := distance / (wgs84B * a)
+	var sinSigma, cosSigma, cos2SigmaM float64
+	for i := 0; i < vincentyIterations; i++ {
+		cos2SigmaM = math.Cos(2*sigma1 + sigma)
+		sinSigma, cosSigma = math.Sin(sigma), math.Cos(sigma)
+		previous := sigma
+		sigma = distance/(wgs84B*a) + deltaSigma(b, sinSigma, cosSigma, cos2SigmaM)
+		if math.Abs(sigma-previous) < vincentyTolerance {
+			break
+		}
+	}
+	sinSigma, cosSigma = math.Sin(sigma), math.Cos(sigma)
+	cos2SigmaM = math.Cos(2*sigma1 + sigma)
+
+	x := sinU1*sinSigma - cosU1*cosSigma*cosAlpha1
+	lat2 := math.Atan2(sinU1*cosSigma+cosU1*sinSigma*cosAlpha1, (1-wgs84F)*math.Hypot(sinAlpha, x))
+	lambda := math.Atan2(sinSigma*sinAlpha1, cosU1*cosSigma-sinU1*sinSigma*cosAlpha1)
+	c := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+	l := lambda - (1-c)*wgs84F*sinAlpha*
+		(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+
+	lon2 := lon + l*180/math.Pi
+	return lat2 * 180 / math.Pi, math.Mod(lon2+540, 360) - 180
+}
+
+// IntermediatePoint computes the point a fraction of the way along the geodesic from
+// point 1 to point 2
+//
+// Parameters:
+//   - lat1, lon1: Coordinates of the starting point in decimal degrees
+//   - lat2, lon2: Coordinates of the end point in decimal degrees
+//   - fraction: The share of the distance to travel, 0 at point 1 and 1 at point 2
+//
+// Returns:
+//   - The latitude and longitude of the intermediate point in decimal degrees
+func IntermediatePoint(lat1, lon1, lat2, lon2, fraction float64) (float64, float64) {
+	distance, bearing, ok := vincentyInverse(lat1, lon1, lat2, lon2)
+	if !ok {
+		return intermediatePoint(lat1, lon1, lat2, lon2, fraction)
+	}
+	if distance == 0 {
+		return lat1, lon1
+	}
+	return Destination(lat1, lon1, bearing, distance*fraction)
+}
+
+// RouteMetrics summarizes the lengths of the legs of a route
+type RouteMetrics struct {
+	// Legs holds the geodesic length in meters of each leg between consecutive waypoints
+	Legs []float64
+
+	// Total is the length of the whole route in meters
+	Total float64
+
+	// Shortest and Longest are the lengths of the shortest and longest legs in meters
+	Shortest float64
+	Longest  float64
+}
+
+// MeasureRoute computes the horizontal leg lengths of a route with Distance
+func MeasureRoute(waypoints []*missioncsv.LitchiWaypoint) RouteMetrics {
+	var metrics RouteMetrics
+	for i := 1; i < len(waypoints); i++ {
+		prev, wp := waypoints[i-1], waypoints[i]
+		leg := Distance(prev.Point.Latitude, prev.Point.Longitude, wp.Point.Latitude, wp.Point.Longitude)
+		metrics.Legs = append(metrics.Legs, leg)
+		metrics.Total += leg
+		if i == 1 || leg < metrics.Shortest {
+			metrics.Shortest = leg
+		}
+		metrics.Longest = math.Max(metrics.Longest, leg)
+	}
+	return metrics
+}
+
+// logRouteMetrics reports the length of every leg at debug level and a summary of the
+// route at info level
+func logRouteMetrics(metrics RouteMetrics) {
+	for i, leg := range metrics.Legs {
+		slog.Debug("Leg length", "from", i+1, "to", i+2, "meters", math.Round(leg*10)/10)
+	}
+	slog.Info("Route length",
+		"legs", len(metrics.Legs),
+		"totalMeters", math.Round(metrics.Total*10)/10,
+		"shortestMeters", math.Round(metrics.Shortest*10)/10,
+		"longestMeters", math.Round(metrics.Longest*10)/10)
+}
+
+// vincentyInverse solves the inverse geodesic problem on the WGS84 ellipsoid, returning
+// the distance in meters and the initial bearing in degrees. ok is false when the
+// iteration does not converge.
+func vincentyInverse(lat1, lon1, lat2, lon2 float64) (distance, bearing float64, ok bool) {
+	if lat1 == lat2 && lon1 == lon2 {
+		return 0, 0, true
+	}
+
+	l := (lon2 - lon1) * math.Pi / 180
+	u1 := math.Atan((1 - wgs84F) * math.Tan(lat1*math.Pi/180))
+	u2 := math.Atan((1 - wgs84F) * math.Tan(lat2*math.Pi/180))
+	sinU1, cosU1 := math.Sin(u1), math.Cos(u1)
+	sinU2, cosU2 := math.Sin(u2), math.Cos(u2)
+
+	lambda := l
+	var sinLambda, cosLambda, sinSigma, cosSigma, sigma, cosSqAlpha, cos2SigmaM float64
+	converged := false
+	for i := 0; i < vincentyIterations; i++ {
+		sinLambda, cosLambda = math.Sin(lambda), math.Cos(lambda)
+		sinSigma = math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			return 0, 0, true
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+		cos2SigmaM = 0 // Both points on the equator
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+		c := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+		previous := lambda
+		lambda = l + (1-c)*wgs84F*sinAlpha*
+			(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-previous) < vincentyTolerance {
+			converged = true
+			break
+		}
+	}
+	if !converged {
+		return 0, 0, false
+	}
+
+	a, b := vincentyCoefficients(cosSqAlpha)
+	distance = wgs84B * a * (sigma - deltaSigma(b, sinSigma, cosSigma, cos2SigmaM))
+	bearing = math.Atan2(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda) * 180 / math.Pi
+	return distance, normalizeHeading(bearing), true
+}
+
+// vincentyCoefficients returns Vincenty's series coefficients A and B for a geodesic
+// with the given squared cosine of its azimuth at the equator
+func vincentyCoefficients(cosSqAlpha float64) (a, b float64) {
+	uSq := cosSqAlpha * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+	a = 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	b = uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+	return a, b
+}
+
+// deltaSigma returns Vincenty's correction to the angular distance on the auxiliary sphere
+func deltaSigma(b, sinSigma, cosSigma, cos2SigmaM float64) float64 {
+	return b * sinSigma * (cos2SigmaM + b/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+		b/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+}
+
+// earthRadius is the mean Earth radius in meters, used where a spherical Earth is
+// accurate enough
+const earthRadius = 6371008.8
+
+// haversineDistance computes the great-circle distance in meters between two points
+// given in decimal degrees, assuming a spherical Earth
+func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	latDiffRad := (lat2 - lat1) * math.Pi / 180
+	lonDiffRad := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(latDiffRad/2)*math.Sin(latDiffRad/2) +
+		math.Cos(lat1Rad)*math.Cos(lat2Rad)*math.Sin(lonDiffRad/2)*math.Sin(lonDiffRad/2)
+	return 2 * earthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// intermediatePoint returns the point a fraction of the way along the great circle
+// from point 1 to point 2 on a sphere of the mean Earth radius
+func intermediatePoint(lat1, lon1, lat2, lon2, fraction float64) (lat, lon float64) {
+	lat1Rad := lat1 * math.Pi / 180
+	lon1Rad := lon1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	lon2Rad := lon2 * math.Pi / 180
+
+	angular := haversineDistance(lat1, lon1, lat2, lon2) / earthRadius
+	if angular < 1e-12 {
+		return lat1, lon1
+	}
+	a := math.Sin((1-fraction)*angular) / math.Sin(angular)
+	b := math.Sin(fraction*angular) / math.Sin(angular)
+
+	x := a*math.Cos(lat1Rad)*math.Cos(lon1Rad) + b*math.Cos(lat2Rad)*math.Cos(lon2Rad)
+	y := a*math.Cos(lat1Rad)*math.Sin(lon1Rad) + b*math.Cos(lat2Rad)*math.Sin(lon2Rad)
+	z := a*math.Sin(lat1Rad) + b*math.Sin(lat2Rad)
+
+	lat = math.Atan2(z, math.Hypot(x, y)) * 180 / math.Pi
+	lon = math.Atan2(y, x) * 180 / math.Pi
+	return lat, lon
+}
@@ -0,0 +1,94 @@
+package fp2lm_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"math"
+	"testing"
+)
+
+// Flinders Peak and Buninyong, the classic test line from Vincenty's paper
+const (
+	flindersLat, flindersLon   = -37.95103341666667, 144.42486788888888
+	buninyongLat, buninyongLon = -37.65282113888889, 143.92649552777777
+	flindersBuninyongDistance  = 54972.271
+	flindersBuninyongBearing   = 306.8681583 // 306°52'05.37"
+)
+
+// TestDistance checks geodesic distances against known values
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		expected, tolerance    float64
+	}{
+		{"Same point", 43, -89, 43, -89, 0, 0},
+		{"Flinders Peak to Buninyong", flindersLat, flindersLon, buninyongLat, buninyongLon, flindersBuninyongDistance, 0.001},
+		{"One degree of latitude at the equator", 0, 0, 1, 0, 110574.389, 0.001},
+		{"One degree of longitude at the equator", 0, 0, 0, 1, 111319.491, 0.001},
+		{"Nearly antipodal falls back to a sphere", 0, 0, 0.5, 179.7, 19950277.3, 0.1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fp2lm.Distance(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(got-tt.expected) > tt.tolerance {
+				t.Errorf("expected %.3f m, got %.3f m", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestDestination checks Vincenty's direct formula against the inverse test line
+func TestDestination(t *testing.T) {
+	lat, lon := fp2lm.Destination(flindersLat, flindersLon, flindersBuninyongBearing, flindersBuninyongDistance)
+	if math.Abs(lat-buninyongLat) > 1e-8 || math.Abs(lon-buninyongLon) > 1e-8 {
+		t.Errorf("expected %.8f, %.8f, got %.8f, %.8f", buninyongLat, buninyongLon, lat, lon)
+	}
+
+	// Crossing the antimeridian wraps the longitude
+	_, lon = fp2lm.Destination(0, 179.9995, 90, 111.319)
+	if math.Abs(lon-(-179.9995)) > 1e-6 {
+		t.Errorf("expected longitude -179.9995, got %.7f", lon)
+	}
+}
+
+// TestIntermediatePoint checks that intermediate points divide the geodesic evenly
+func TestIntermediatePoint(t *testing.T) {
+	lat, lon := fp2lm.IntermediatePoint(flindersLat, flindersLon, buninyongLat, buninyongLon, 0.25)
+	first := fp2lm.Distance(flindersLat, flindersLon, lat, lon)
+	rest := fp2lm.Distance(lat, lon, buninyongLat, buninyongLon)
+	if math.Abs(first-flindersBuninyongDistance/4) > 0.001 || math.Abs(rest-flindersBuninyongDistance*3/4) > 0.001 {
+		t.Errorf("expected legs of %.3f and %.3f m, got %.3f and %.3f m",
+			flindersBuninyongDistance/4, flindersBuninyongDistance*3/4, first, rest)
+	}
+
+	if lat, lon := fp2lm.IntermediatePoint(43, -89, 43, -89, 0.5); lat != 43 || lon != -89 {
+		t.Errorf("expected the same point, got %.7f, %.7f", lat, lon)
+	}
+}
+
+// TestMeasureRoute checks leg and total lengths of an L-shaped route
+func TestMeasureRoute(t *testing.T) {
+	metrics := fp2lm.MeasureRoute(lShapedRoute())
+
+	expected := []float64{1113.195, 1105.743}
+	if len(metrics.Legs) != len(expected) {
+		t.Fatalf("expected %d legs, got %d", len(expected), len(metrics.Legs))
+	}
+	for i, leg := range metrics.Legs {
+		if math.Abs(leg-expected[i]) > 0.001 {
+			t.Errorf("leg %d: expected %.3f m, got %.3f m", i, expected[i], leg)
+		}
+	}
+	if math.Abs(metrics.Total-(expected[0]+expected[1])) > 0.002 {
+		t.Errorf("expected total %.3f m, got %.3f m", expected[0]+expected[1], metrics.Total)
+	}
+	if metrics.Shortest != metrics.Legs[1] || metrics.Longest != metrics.Legs[0] {
+		t.Errorf("expected shortest %.3f and longest %.3f, got %.3f and %.3f",
+			metrics.Legs[1], metrics.Legs[0], metrics.Shortest, metrics.Longest)
+	}
+
+	if metrics := fp2lm.MeasureRoute(nil); metrics.Total != 0 || len(metrics.Legs) != 0 {
+		t.Errorf("expected an empty route to have no legs, got %+v", metrics)
+	}
+}
@@ -0,0 +1,243 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+)
+
+// ZoneKind distinguishes areas a mission must avoid from areas it must stay within
+type ZoneKind int
+
+const (
+	// ZoneExclusion is a no-fly area such as an airport or restricted zone
+	ZoneExclusion ZoneKind = iota
+	// ZoneInclusion is an area the whole mission must stay inside
+	ZoneInclusion
+)
+
+// String returns the lowercase name of the zone kind
+func (k ZoneKind) String() string {
+	if k == ZoneInclusion {
+		return "inclusion"
+	}
+	return "exclusion"
+}
+
+// Zone is a named polygon used to check a mission against a geofence
+type Zone struct {
+	// Name identifies the zone in violation reports
+	Name string
+
+	// Kind determines whether the mission must stay out of or inside the zone
+	Kind ZoneKind
+
+	// Boundary is the outer ring of the polygon as latitude, longitude pairs. The ring
+	// may be closed by repeating the first vertex, but does not have to be.
+	Boundary [][2]float64
+
+	// Holes are rings cut out of the polygon, in the same form as Boundary
+	Holes [][][2]float64
+}
+
+// GeofenceViolation reports a waypoint or leg that breaks a geofence zone
+type GeofenceViolation struct {
+	// Zone is the name of the zone broken, empty for a waypoint outside every
+	// inclusion zone
+	Zone string
+
+	// Kind is the kind of zone broken
+	Kind ZoneKind
+
+	// Waypoint is the index (from 0) of the offending waypoint, or of the waypoint
+	// starting the offending leg
+	Waypoint int
+
+	// Leg is set when the leg from Waypoint to the next waypoint crosses the zone's
+	// boundary although both of its ends are allowed
+	Leg bool
+}
+
+// String describes the violation with waypoints numbered from 1
+func (v GeofenceViolation) String() string {
+	switch {
+	case v.Kind == ZoneExclusion && v.Leg:
+		return fmt.Sprintf("leg from waypoint %d to %d crosses exclusion zone %q", v.Waypoint+1, v.Waypoint+2, v.Zone)
+	case v.Kind == ZoneExclusion:
+		return fmt.Sprintf("waypoint %d is inside exclusion zone %q", v.Waypoint+1, v.Zone)
+	case v.Leg:
+		return fmt.Sprintf("leg from waypoint %d to %d leaves inclusion zone %q", v.Waypoint+1, v.Waypoint+2, v.Zone)
+	default:
+		return fmt.Sprintf("waypoint %d is outside every inclusion zone", v.Waypoint+1)
+	}
+}
+
+// CheckGeofence checks every waypoint and every straight leg of a mission against
+// a set of zones
+//
+// Parameters:
+//   - waypoints: The mission waypoints in flight order
+//   - zones: Exclusion and inclusion zones, in any order
+//
+// Returns:
+//   - The violations found, ordered by waypoint. An empty result means the mission
+//     respects every zone.
+//
+// Waypoints must lie outside every exclusion zone and, when there are any inclusion
+// zones, inside at least one of them. A leg whose ends are both allowed is reported
+// when it cuts across an exclusion zone, or when no single inclusion zone contains
+// all of it. Zones are compared on a local plane around the mission, which is
+// accurate for mission-sized areas.
+func CheckGeofence(waypoints []*missioncsv.LitchiWaypoint, zones []Zone) []GeofenceViolation {
+	if len(waypoints) == 0 || len(zones) == 0 {
+		return nil
+	}
+
+	plane := newWaypointPlane(waypoints)
+	points := make([][2]float64, len(waypoints))
+	for i, wp := range waypoints {
+		x, y := plane.ToXY(wp.Point.Latitude, wp.Point.Longitude)
+		points[i] = [2]float64{x, y}
+	}
+
+	var exclusions, inclusions []planeZone
+	for _, zone := range zones {
+		projected := projectZone(plane, zone)
+		if zone.Kind == ZoneInclusion {
+			inclusions = append(inclusions, projected)
+		} else {
+			exclusions = append(exclusions, projected)
+		}
+	}
+
+	var violations []GeofenceViolation
+	for i, p := range points {
+		// Check the waypoint itself
+		for _, zone := range exclusions {
+			if zone.contains(p) {
+				violations = append(violations, GeofenceViolation{Zone: zone.name, Kind: ZoneExclusion, Waypoint: i})
+			}
+		}
+		if len(inclusions) > 0 && !anyContains(inclusions, p) {
+			violations = append(violations, GeofenceViolation{Kind: ZoneInclusion, Waypoint: i})
+		}
+
+		if i == len(points)-1 {
+			break
+		}
+
+		// Check the leg to the next waypoint, when both of its ends are allowed
+		next := points[i+1]
+		for _, zone := range exclusions {
+			if !zone.contains(p) && !zone.contains(next) && zone.crosses(p, next) {
+				violations = append(violations, GeofenceViolation{Zone: zone.name, Kind: ZoneExclusion, Waypoint: i, Leg: true})
+			}
+		}
+		if len(inclusions) > 0 && anyContains(inclusions, p) && anyContains(inclusions, next) {
+			var start *planeZone
+			contained := false
+			for j := range inclusions {
+				zone := &inclusions[j]
+				if !zone.contains(p) {
+					continue
+				}
+				if start == nil {
+					start = zone
+				}
+				if zone.contains(next) && !zone.crosses(p, next) {
+					contained = true
+					break
+				}
+			}
+			if !contained {
+				violations = append(violations, GeofenceViolation{Zone: start.name, Kind: ZoneInclusion, Waypoint: i, Leg: true})
+			}
+		}
+	}
+	return violations
+}
+
+// planeZone is a zone projected onto a local plane, with every ring closed
+type planeZone struct {
+	name  string
+	rings [][][2]float64
+}
+
+// projectZone projects a zone's rings onto a local plane
+func projectZone(plane LocalPlane, zone Zone) planeZone {
+	projected := planeZone{name: zone.Name}
+	for _, ring := range append([][][2]float64{zone.Boundary}, zone.Holes...) {
+		var points [][2]float64
+		for _, vertex := range ring {
+			x, y := plane.ToXY(vertex[0], vertex[1])
+			points = append(points, [2]float64{x, y})
+		}
+		if n := len(points); n > 0 && points[0] != points[n-1] {
+			points = append(points, points[0])
+		}
+		projected.rings = append(projected.rings, points)
+	}
+	return projected
+}
+
+// contains reports whether a point lies inside the zone's boundary and outside its
+// holes, using the even-odd rule across all rings
+func (z planeZone) contains(p [2]float64) bool {
+	inside := false
+	for _, ring := range z.rings {
+		for i := 1; i < len(ring); i++ {
+			a, b := ring[i-1], ring[i]
+			if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < a[0]+(p[1]-a[1])*(b[0]-a[0])/(b[1]-a[1]) {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// crosses reports whether the segment from p to q intersects any edge of the zone
+func (z planeZone) crosses(p, q [2]float64) bool {
+	for _, ring := range z.rings {
+		for i := 1; i < len(ring); i++ {
+			if segmentsIntersect(p, q, ring[i-1], ring[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// anyContains reports whether any of the zones contains a point
+func anyContains(zones []planeZone, p [2]float64) bool {
+	for _, zone := range zones {
+		if zone.contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentsIntersect reports whether the segments from a to b and from c to d touch
+func segmentsIntersect(a, b, c, d [2]float64) bool {
+	d1 := orientation(c, d, a)
+	d2 := orientation(c, d, b)
+	d3 := orientation(a, b, c)
+	d4 := orientation(a, b, d)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(c, d, a)) || (d2 == 0 && onSegment(c, d, b)) ||
+		(d3 == 0 && onSegment(a, b, c)) || (d4 == 0 && onSegment(a, b, d))
+}
+
+// orientation returns the cross product of (b - a) and (c - a): positive when c is to
+// the left of the line from a to b, negative to the right and zero when collinear
+func orientation(a, b, c [2]float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+// onSegment reports whether p, known to be collinear with a and b, lies between them
+func onSegment(a, b, p [2]float64) bool {
+	return p[0] >= math.Min(a[0], b[0]) && p[0] <= math.Max(a[0], b[0]) &&
+		p[1] >= math.Min(a[1], b[1]) && p[1] <= math.Max(a[1], b[1])
+}
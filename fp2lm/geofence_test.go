@@ -0,0 +1,94 @@
+package fp2lm_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// square returns a closed ring of latitude, longitude pairs around a square
+func square(south, west, north, east float64) [][2]float64 {
+	return [][2]float64{{south, west}, {south, east}, {north, east}, {north, west}, {south, west}}
+}
+
+// TestCheckGeofenceExclusion checks waypoints and legs against a no-fly square
+func TestCheckGeofenceExclusion(t *testing.T) {
+	zones := []fp2lm.Zone{{Name: "Airport", Kind: fp2lm.ZoneExclusion, Boundary: square(0.001, 0.001, 0.002, 0.002)}}
+	waypoints := route(
+		[2]float64{0.0015, 0},
+		[2]float64{0.0015, 0.003},  // leg from the first waypoint cuts through the square
+		[2]float64{0.0015, 0.0015}, // inside the square
+		[2]float64{0.003, 0.0015},
+		[2]float64{0.003, 0}, // clear of the square
+	)
+
+	expected := []fp2lm.GeofenceViolation{
+		{Zone: "Airport", Kind: fp2lm.ZoneExclusion, Waypoint: 0, Leg: true},
+		{Zone: "Airport", Kind: fp2lm.ZoneExclusion, Waypoint: 2},
+	}
+	violations := fp2lm.CheckGeofence(waypoints, zones)
+	if !reflect.DeepEqual(violations, expected) {
+		t.Errorf("expected %v, got %v", expected, violations)
+	}
+
+	// A hole in the zone is allowed
+	zones[0].Holes = [][][2]float64{square(0.0012, 0.0012, 0.0018, 0.0018)}
+	violations = fp2lm.CheckGeofence(waypoints[2:4], zones)
+	if len(violations) != 1 || !violations[0].Leg {
+		t.Errorf("expected only the leg leaving the hole to be reported, got %v", violations)
+	}
+}
+
+// TestCheckGeofenceInclusion checks that waypoints and legs stay inside an L-shaped area
+func TestCheckGeofenceInclusion(t *testing.T) {
+	zones := []fp2lm.Zone{{
+		Name:     "Site",
+		Kind:     fp2lm.ZoneInclusion,
+		Boundary: [][2]float64{{0, 0}, {0, 0.002}, {0.001, 0.002}, {0.001, 0.001}, {0.002, 0.001}, {0.002, 0}},
+	}}
+	waypoints := route(
+		[2]float64{0.0005, 0.0015},
+		[2]float64{0.0015, 0.0005}, // leg cuts across the notch of the L
+		[2]float64{0.0005, 0.0005},
+		[2]float64{0.003, 0.0005}, // outside the site
+	)
+
+	expected := []fp2lm.GeofenceViolation{
+		{Zone: "Site", Kind: fp2lm.ZoneInclusion, Waypoint: 0, Leg: true},
+		{Kind: fp2lm.ZoneInclusion, Waypoint: 3},
+	}
+	violations := fp2lm.CheckGeofence(waypoints, zones)
+	if !reflect.DeepEqual(violations, expected) {
+		t.Errorf("expected %v, got %v", expected, violations)
+	}
+
+	if got := violations[1].String(); got != "waypoint 4 is outside every inclusion zone" {
+		t.Errorf("unexpected description %q", got)
+	}
+}
+
+// TestProcessWithGeofence checks that violations fail the conversion or only warn
+func TestProcessWithGeofence(t *testing.T) {
+	options := fp2lm.DefaultOptions()
+	options.Zones = []fp2lm.Zone{{Name: "Stadium", Boundary: square(42.99, -88.9988, 43.01, -88.9982)}}
+
+	var output strings.Builder
+	err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options)
+	if err == nil || !strings.Contains(err.Error(), `leg from waypoint 2 to 3 crosses exclusion zone "Stadium"`) {
+		t.Errorf("expected a geofence error, got %v", err)
+	}
+	if output.Len() != 0 {
+		t.Error("expected nothing to be written when the geofence is broken")
+	}
+
+	options.GeofenceAction = "warn"
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options); err != nil {
+		t.Errorf("expected only a warning, got %v", err)
+	}
+
+	options.GeofenceAction = "ignore"
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options); err == nil {
+		t.Error("expected an error for an unknown geofence action")
+	}
+}
@@ -0,0 +1,127 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// HeadingStrategy selects how aircraft headings are assigned to waypoints
+type HeadingStrategy struct {
+	// Mode determines how headings are computed:
+	// "course" faces the next waypoint (the last waypoint keeps the previous heading),
+	// "fixed" uses Azimuth everywhere, "offset" adds Offset to the course,
+	// "poi" faces the point of interest, and "smooth" averages the incoming and
+	// outgoing course at each waypoint. An empty mode means "course" in Apply;
+	// Convert and generators such as survey.Facade and polyorbit.Stack document
+	// their own default for it.
+	Mode string
+
+	// Azimuth is the heading in degrees from North used by the "fixed" mode
+	Azimuth float64
+
+	// Offset is the angle in degrees added to the course by the "offset" mode,
+	// for example 90 to point the camera to the right of the direction of travel
+	Offset float64
+}
+
+// Validate checks that the strategy can be applied. poi is the point of interest
+// available to the "poi" mode, and may be nil.
+func (s HeadingStrategy) Validate(poi *missioncsv.POI) error {
+	switch strings.ToLower(s.Mode) {
+	case "", "course", "fixed", "offset", "smooth":
+		return nil
+	case "poi":
+		if poi == nil {
+			return fmt.Errorf("heading mode 'poi' requires a point of interest")
+		}
+		return nil
+	default:
+		return fmt.Errorf("heading mode must be one of 'course', 'fixed', 'offset', 'poi' or 'smooth', got %q", s.Mode)
+	}
+}
+
+// Apply sets the heading of every waypoint according to the strategy
+//
+// Parameters:
+//   - waypoints: The mission waypoints in flight order
+//   - poi: The point of interest used by the "poi" mode, may be nil for other modes
+//
+// Route-based modes need at least two waypoints; a single waypoint keeps its heading.
+func (s HeadingStrategy) Apply(waypoints []*missioncsv.LitchiWaypoint, poi *missioncsv.POI) error {
+	if err := s.Validate(poi); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(s.Mode) {
+	case "fixed":
+		for _, wp := range waypoints {
+			wp.Heading = float32(normalizeHeading(s.Azimuth))
+		}
+		return nil
+	case "poi":
+		for _, wp := range waypoints {
+			wp.Heading = float32(CalculateBearing(wp.Point.Latitude, wp.Point.Longitude, poi.Latitude, poi.Longitude))
+		}
+		return nil
+	}
+
+	if len(waypoints) < 2 {
+		return nil
+	}
+
+	courses := legCourses(waypoints)
+	for i, wp := range waypoints {
+		// The last waypoint has no outgoing leg and keeps the course of the one before it
+		course := courses[len(courses)-1]
+		if i < len(courses) {
+			course = courses[i]
+		}
+
+		switch strings.ToLower(s.Mode) {
+		case "offset":
+			course = normalizeHeading(course + s.Offset)
+		case "smooth":
+			if i > 0 && i < len(courses) {
+				course = meanHeading(courses[i-1], courses[i])
+			}
+		}
+		wp.Heading = float32(course)
+	}
+	return nil
+}
+
+// legCourses returns the initial bearing of each leg between consecutive waypoints
+func legCourses(waypoints []*missioncsv.LitchiWaypoint) []float64 {
+	courses := make([]float64, len(waypoints)-1)
+	for i := range courses {
+		current := waypoints[i]
+		next := waypoints[i+1]
+		courses[i] = CalculateBearing(current.Point.Latitude, current.Point.Longitude,
+			next.Point.Latitude, next.Point.Longitude)
+	}
+	return courses
+}
+
+// meanHeading returns the circular mean of two headings in degrees. When the headings
+// are opposite the mean is undefined and the second heading is returned.
+func meanHeading(a, b float64) float64 {
+	aRad := a * math.Pi / 180
+	bRad := b * math.Pi / 180
+	x := math.Cos(aRad) + math.Cos(bRad)
+	y := math.Sin(aRad) + math.Sin(bRad)
+	if math.Abs(x) < 1e-9 && math.Abs(y) < 1e-9 {
+		return b
+	}
+	return normalizeHeading(math.Atan2(y, x) * 180 / math.Pi)
+}
+
+// normalizeHeading wraps a heading in degrees into the range [0, 360)
+func normalizeHeading(heading float64) float64 {
+	heading = math.Mod(heading, 360)
+	if heading < 0 {
+		heading += 360
+	}
+	return heading
+}
@@ -0,0 +1,66 @@
+package fp2lm_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"math"
+	"testing"
+)
+
+// route returns waypoints at the given latitude, longitude pairs
+func route(coords ...[2]float64) []*missioncsv.LitchiWaypoint {
+	var waypoints []*missioncsv.LitchiWaypoint
+	for _, c := range coords {
+		wp := missioncsv.NewLitchiWaypoint()
+		wp.Point.Latitude, wp.Point.Longitude = c[0], c[1]
+		waypoints = append(waypoints, wp)
+	}
+	return waypoints
+}
+
+// lShapedRoute returns three waypoints flying east and then north
+func lShapedRoute() []*missioncsv.LitchiWaypoint {
+	return route([2]float64{0, 0}, [2]float64{0, 0.01}, [2]float64{0.01, 0.01})
+}
+
+// TestHeadingStrategy checks each heading mode on an L-shaped route
+func TestHeadingStrategy(t *testing.T) {
+	poi := &missioncsv.POI{Latitude: 0, Longitude: 0.02}
+
+	tests := []struct {
+		name     string
+		strategy fp2lm.HeadingStrategy
+		expected []float64
+	}{
+		{"Course", fp2lm.HeadingStrategy{Mode: "course"}, []float64{90, 0, 0}},
+		{"Empty mode is course", fp2lm.HeadingStrategy{}, []float64{90, 0, 0}},
+		{"Fixed", fp2lm.HeadingStrategy{Mode: "fixed", Azimuth: -45}, []float64{315, 315, 315}},
+		{"Offset", fp2lm.HeadingStrategy{Mode: "offset", Offset: 90}, []float64{180, 90, 90}},
+		{"Smooth", fp2lm.HeadingStrategy{Mode: "smooth"}, []float64{90, 45, 0}},
+		{"POI", fp2lm.HeadingStrategy{Mode: "POI"}, []float64{90, 90, 135}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waypoints := lShapedRoute()
+			if err := tt.strategy.Apply(waypoints, poi); err != nil {
+				t.Fatalf("Apply returned error: %v", err)
+			}
+			for i, wp := range waypoints {
+				if math.Abs(float64(wp.Heading)-tt.expected[i]) > 0.1 {
+					t.Errorf("waypoint %d: expected heading %.1f, got %.1f", i, tt.expected[i], wp.Heading)
+				}
+			}
+		})
+	}
+}
+
+// TestHeadingStrategyInvalid checks that unusable strategies are rejected
+func TestHeadingStrategyInvalid(t *testing.T) {
+	if err := (fp2lm.HeadingStrategy{Mode: "poi"}).Apply(lShapedRoute(), nil); err == nil {
+		t.Error("expected an error for 'poi' without a point of interest")
+	}
+	if err := (fp2lm.HeadingStrategy{Mode: "sideways"}).Validate(nil); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
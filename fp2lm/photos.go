@@ -0,0 +1,198 @@
+package fp2lm
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Photo triggers, as written to the trigger column and property
+const (
+	triggerAction   = "action"
+	triggerInterval = "interval"
+)
+
+// PhotoPosition is a predicted position of the aircraft when a photo is taken
+type PhotoPosition struct {
+	// Waypoint is the index (from 0) of the waypoint taking the photo, or of the
+	// waypoint starting the leg it is taken on
+	Waypoint int
+
+	// Interval is true for photos triggered by the distance interval, and false for
+	// take-photo actions at waypoints
+	Interval bool
+
+	// Distance is the distance in meters along the route
+	Distance float64
+
+	Latitude  float64
+	Longitude float64
+
+	// Altitude is in meters, measured as the waypoint's altitude mode specifies
+	Altitude float64
+
+	// Heading is the aircraft heading in degrees clockwise from north (0-360)
+	Heading float64
+}
+
+// SimulatePhotos walks the route and predicts where Litchi takes each photo
+//
+// A photo is taken at a waypoint for every take-photo action, with the waypoint's own
+// altitude and heading. A waypoint with a positive PhotoDistInterval also takes photos
+// every interval meters along the leg that follows it, counting from the waypoint, up
+// to but not including the next waypoint. Along a leg the altitude is interpolated
+// linearly, and the heading turns evenly from the heading of one waypoint to the next
+// in the direction set by the next waypoint's RotationDir (0 clockwise, 1
+// counter-clockwise).
+//
+// The simulation is an estimate. Time intervals are not simulated, since the ground
+// speed is not known in advance, and headings between waypoints are interpolated even
+// when Litchi would keep the aircraft facing a point of interest along the leg.
+func SimulatePhotos(waypoints []*missioncsv.LitchiWaypoint) []PhotoPosition {
+	legs := MeasureRoute(waypoints).Legs
+	var photos []PhotoPosition
+	var travelled float64
+	for i, wp := range waypoints {
+		for _, action := range wp.Actions {
+			if action.Type == missioncsv.ActionTakePhoto {
+				photos = append(photos, PhotoPosition{
+					Waypoint:  i,
+					Distance:  travelled,
+					Latitude:  wp.Point.Latitude,
+					Longitude: wp.Point.Longitude,
+					Altitude:  wp.Point.Altitude,
+					Heading:   normalizeHeading(float64(wp.Heading)),
+				})
+			}
+		}
+		if i == len(legs) {
+			break
+		}
+
+		next, length := waypoints[i+1], legs[i]
+		interval := float64(wp.PhotoDistInterval)
+		if interval > 0 {
+			startHeading := normalizeHeading(float64(wp.Heading))
+			turn := headingTurn(startHeading, normalizeHeading(float64(next.Heading)), next.RotationDir)
+			for distance := interval; distance < length; distance += interval {
+				fraction := distance / length
+				lat, lon := IntermediatePoint(wp.Point.Latitude, wp.Point.Longitude,
+					next.Point.Latitude, next.Point.Longitude, fraction)
+				photos = append(photos, PhotoPosition{
+					Waypoint:  i,
+					Interval:  true,
+					Distance:  travelled + distance,
+					Latitude:  lat,
+					Longitude: lon,
+					Altitude:  wp.Point.Altitude + (next.Point.Altitude-wp.Point.Altitude)*fraction,
+					Heading:   normalizeHeading(startHeading + turn*fraction),
+				})
+			}
+		}
+		travelled += length
+	}
+	return photos
+}
+
+// headingTurn returns the signed turn in degrees from one heading to another, positive
+// clockwise, in the given Litchi rotation direction (0 clockwise, 1 counter-clockwise)
+func headingTurn(from, to float64, rotationDir int8) float64 {
+	clockwise := math.Mod(to-from+360, 360)
+	if rotationDir == 1 && clockwise != 0 {
+		return clockwise - 360
+	}
+	return clockwise
+}
+
+// trigger names what took a photo
+func (p PhotoPosition) trigger() string {
+	if p.Interval {
+		return triggerInterval
+	}
+	return triggerAction
+}
+
+// WritePhotosCSV writes predicted photo positions as CSV with a header row, numbering
+// photos and waypoints from 1
+func WritePhotosCSV(w io.Writer, photos []PhotoPosition) error {
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write([]string{"photo", "waypoint", "trigger", "distance(m)", "latitude", "longitude", "altitude(m)", "heading(deg)"})
+	for i, p := range photos {
+		csvWriter.Write([]string{
+			strconv.Itoa(i + 1),
+			strconv.Itoa(p.Waypoint + 1),
+			p.trigger(),
+			fmt.Sprintf("%.1f", p.Distance),
+			fmt.Sprintf("%.7f", p.Latitude),
+			fmt.Sprintf("%.7f", p.Longitude),
+			fmt.Sprintf("%.1f", p.Altitude),
+			fmt.Sprintf("%.1f", p.Heading),
+		})
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write photo positions: %w", err)
+	}
+	return nil
+}
+
+// photoFeature is a GeoJSON point feature for a photo position
+type photoFeature struct {
+	Type     string `json:"type"`
+	Geometry struct {
+		Type        string     `json:"type"`
+		Coordinates [3]float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties photoProperties `json:"properties"`
+}
+
+// photoProperties holds the attributes of a photo position in GeoJSON
+type photoProperties struct {
+	Photo    int     `json:"photo"`
+	Waypoint int     `json:"waypoint"`
+	Trigger  string  `json:"trigger"`
+	Distance float64 `json:"distance"`
+	Altitude float64 `json:"altitude"`
+	Heading  float64 `json:"heading"`
+}
+
+// WritePhotosGeoJSON writes predicted photo positions as a GeoJSON FeatureCollection
+// of points, with the altitude as the third coordinate and the photo and waypoint
+// numbers, trigger, distance along the route, altitude and heading as properties
+func WritePhotosGeoJSON(w io.Writer, photos []PhotoPosition) error {
+	collection := struct {
+		Type     string         `json:"type"`
+		Features []photoFeature `json:"features"`
+	}{Type: "FeatureCollection", Features: []photoFeature{}}
+
+	round := func(value, places float64) float64 {
+		scale := math.Pow(10, places)
+		return math.Round(value*scale) / scale
+	}
+	for i, p := range photos {
+		var feature photoFeature
+		feature.Type = "Feature"
+		feature.Geometry.Type = "Point"
+		feature.Geometry.Coordinates = [3]float64{round(p.Longitude, 7), round(p.Latitude, 7), round(p.Altitude, 1)}
+		feature.Properties = photoProperties{
+			Photo:    i + 1,
+			Waypoint: p.Waypoint + 1,
+			Trigger:  p.trigger(),
+			Distance: round(p.Distance, 1),
+			Altitude: round(p.Altitude, 1),
+			Heading:  round(p.Heading, 1),
+		}
+		collection.Features = append(collection.Features, feature)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(collection); err != nil {
+		return fmt.Errorf("failed to write photo positions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package fp2lm_test
+
+import (
+	"encoding/json"
+	"flightplan2litchimission/fp2lm"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestSimulatePhotos checks photo positions from actions and the distance interval
+func TestSimulatePhotos(t *testing.T) {
+	waypoints := route([2]float64{0, 0}, [2]float64{0, 0.001})
+	waypoints[0].Point.Altitude, waypoints[1].Point.Altitude = 30, 50
+	waypoints[0].Heading, waypoints[1].Heading = 350, 10
+	waypoints[0].PhotoDistInterval = 25
+
+	photos := fp2lm.SimulatePhotos(waypoints)
+	if len(photos) != 6 {
+		t.Fatalf("expected 6 photos, got %d", len(photos))
+	}
+
+	length := fp2lm.Distance(0, 0, 0, 0.001)
+	expectedDistances := []float64{0, 25, 50, 75, 100, length}
+	for i, p := range photos {
+		if math.Abs(p.Distance-expectedDistances[i]) > 1e-6 {
+			t.Errorf("photo %d: expected %.1f m along the route, got %.1f", i+1, expectedDistances[i], p.Distance)
+		}
+		interval := i > 0 && i < 5
+		if p.Interval != interval {
+			t.Errorf("photo %d: expected interval %v, got %v", i+1, interval, p.Interval)
+		}
+	}
+
+	// Halfway along, the heading has turned through north
+	fraction := 50 / length
+	p := photos[2]
+	if p.Waypoint != 0 || math.Abs(p.Altitude-(30+20*fraction)) > 1e-9 || math.Abs(p.Heading-(350+20*fraction)) > 1e-9 {
+		t.Errorf("unexpected photo at 50 m: %+v", p)
+	}
+	if math.Abs(p.Latitude) > 1e-9 || math.Abs(fp2lm.Distance(0, 0, p.Latitude, p.Longitude)-50) > 1e-3 {
+		t.Errorf("expected the photo 50 m east of the start, got %.7f, %.7f", p.Latitude, p.Longitude)
+	}
+	if last := photos[5]; last.Waypoint != 1 || last.Heading != 10 || last.Altitude != 50 {
+		t.Errorf("expected the last photo at waypoint 2, got %+v", last)
+	}
+
+	// Rotating counter-clockwise into waypoint 2 turns the long way, through south
+	waypoints[1].RotationDir = 1
+	p = fp2lm.SimulatePhotos(waypoints)[2]
+	if want := 350 - 340*fraction; math.Abs(p.Heading-want) > 1e-9 {
+		t.Errorf("expected heading %.1f turning counter-clockwise, got %.1f", want, p.Heading)
+	}
+}
+
+// TestWritePhotos checks the CSV and GeoJSON output of photo positions
+func TestWritePhotos(t *testing.T) {
+	photos := []fp2lm.PhotoPosition{
+		{Waypoint: 0, Latitude: 43, Longitude: -89, Altitude: 40, Heading: 90},
+		{Waypoint: 0, Interval: true, Distance: 20, Latitude: 43, Longitude: -88.99975, Altitude: 40, Heading: 90},
+	}
+
+	var b strings.Builder
+	if err := fp2lm.WritePhotosCSV(&b, photos); err != nil {
+		t.Fatalf("WritePhotosCSV returned error: %v", err)
+	}
+	expected := "photo,waypoint,trigger,distance(m),latitude,longitude,altitude(m),heading(deg)\n" +
+		"1,1,action,0.0,43.0000000,-89.0000000,40.0,90.0\n" +
+		"2,1,interval,20.0,43.0000000,-88.9997500,40.0,90.0\n"
+	if b.String() != expected {
+		t.Errorf("expected CSV:\n%s\ngot:\n%s", expected, b.String())
+	}
+
+	b.Reset()
+	if err := fp2lm.WritePhotosGeoJSON(&b, photos); err != nil {
+		t.Fatalf("WritePhotosGeoJSON returned error: %v", err)
+	}
+	var collection struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates []float64
+			}
+			Properties map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal([]byte(b.String()), &collection); err != nil {
+		t.Fatalf("invalid GeoJSON: %v", err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 2 {
+		t.Fatalf("expected a collection of 2 features, got %+v", collection)
+	}
+	second := collection.Features[1]
+	if second.Geometry.Type != "Point" || second.Geometry.Coordinates[0] != -88.99975 ||
+		second.Geometry.Coordinates[1] != 43 || second.Geometry.Coordinates[2] != 40 {
+		t.Errorf("unexpected geometry %+v", second.Geometry)
+	}
+	if second.Properties["photo"] != 2.0 || second.Properties["trigger"] != "interval" || second.Properties["heading"] != 90.0 {
+		t.Errorf("unexpected properties %v", second.Properties)
+	}
+}
@@ -0,0 +1,120 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PitchKeyframe pins the gimbal pitch at a position along the mission
+type PitchKeyframe struct {
+	// Position is a waypoint index (starting at 0), or a distance in meters along the
+	// route when ConverterOptions.PitchKeyframeUnit is "distance"
+	Position float64
+
+	// Pitch is the gimbal pitch in degrees (between -90 and 0)
+	Pitch float64
+}
+
+// validatePitchKeyframes checks the keyframe unit and the range of every keyframe
+func validatePitchKeyframes(keyframes []PitchKeyframe, unit string) error {
+	switch strings.ToLower(unit) {
+	case "", "index", "distance":
+	default:
+		return fmt.Errorf("pitch keyframe unit must be either 'index' or 'distance', got %q", unit)
+	}
+
+	for i, kf := range keyframes {
+		if kf.Position < 0 {
+			return fmt.Errorf("pitch keyframe %d: position must not be negative, got %.1f", i, kf.Position)
+		}
+		if kf.Pitch < -90 || kf.Pitch > 0 {
+			return fmt.Errorf("pitch keyframe %d: pitch must be between -90 and 0 degrees, got %.1f", i, kf.Pitch)
+		}
+	}
+	return nil
+}
+
+// applyPitchKeyframes sets each waypoint's gimbal pitch by interpolating linearly
+// between the keyframes around its position. Waypoints before the first keyframe or
+// after the last one hold that keyframe's pitch. Gimbal mode is set to interpolate
+// so Litchi also ramps the pitch smoothly between waypoints.
+func applyPitchKeyframes(waypoints []*missioncsv.LitchiWaypoint, keyframes []PitchKeyframe, unit string) {
+	if len(keyframes) == 0 {
+		return
+	}
+
+	sorted := make([]PitchKeyframe, len(keyframes))
+	copy(sorted, keyframes)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Position < sorted[j].Position })
+
+	distance := 0.0
+	for i, wp := range waypoints {
+		if i > 0 {
+			prev := waypoints[i-1]
+			distance += Distance(prev.Point.Latitude, prev.Point.Longitude,
+				wp.Point.Latitude, wp.Point.Longitude)
+		}
+
+		position := float64(i)
+		if strings.ToLower(unit) == "distance" {
+			position = distance
+		}
+
+		wp.GimbalPitch = float32(interpolatePitch(sorted, position))
+		wp.GimbalMode = 2 // Interpolate
+	}
+}
+
+// keyframesByDistance converts keyframes positioned by waypoint index to distances in
+// meters along the route, so they stay in place when waypoints are later removed or
+// inserted. Fractional positions fall proportionally along their leg, and positions
+// past the last waypoint continue at the length of the last leg.
+func keyframesByDistance(waypoints []*missioncsv.LitchiWaypoint, keyframes []PitchKeyframe) []PitchKeyframe {
+	if len(keyframes) == 0 || len(waypoints) == 0 {
+		return keyframes
+	}
+
+	legs := MeasureRoute(waypoints).Legs
+	distances := make([]float64, len(waypoints))
+	for i, leg := range legs {
+		distances[i+1] = distances[i] + leg
+	}
+
+	resolved := make([]PitchKeyframe, len(keyframes))
+	for i, kf := range keyframes {
+		index := int(kf.Position)
+		fraction := kf.Position - float64(index)
+		if index >= len(legs) {
+			index, fraction = len(waypoints)-1, kf.Position-float64(len(waypoints)-1)
+		}
+		var leg float64
+		switch {
+		case index < len(legs):
+			leg = legs[index]
+		case len(legs) > 0:
+			leg = legs[len(legs)-1]
+		}
+		resolved[i] = PitchKeyframe{Position: distances[index] + fraction*leg, Pitch: kf.Pitch}
+	}
+	return resolved
+}
+
+// interpolatePitch returns the pitch at position from keyframes sorted by position
+func interpolatePitch(keyframes []PitchKeyframe, position float64) float64 {
+	if position <= keyframes[0].Position {
+		return keyframes[0].Pitch
+	}
+	for i := 1; i < len(keyframes); i++ {
+		prev, next := keyframes[i-1], keyframes[i]
+		if position <= next.Position {
+			if next.Position == prev.Position {
+				return next.Pitch
+			}
+			t := (position - prev.Position) / (next.Position - prev.Position)
+			return prev.Pitch + t*(next.Pitch-prev.Pitch)
+		}
+	}
+	return keyframes[len(keyframes)-1].Pitch
+}
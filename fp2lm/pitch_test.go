@@ -0,0 +1,79 @@
+package fp2lm_test
+
+import (
+	"bytes"
+	"flightplan2litchimission/fp2lm"
+	"strings"
+	"testing"
+)
+
+// eastboundInput is a Flight Planner CSV with five waypoints about 81 m apart
+const eastboundInput = "Waypoint Number,X [m],Y [m],Alt. ASL [m],Alt. AGL [m],xcoord,ycoord\n" +
+	"1,0,0,60,40,-89.0000,43.0\n" +
+	"2,0,0,60,40,-88.9990,43.0\n" +
+	"3,0,0,60,40,-88.9980,43.0\n" +
+	"4,0,0,60,40,-88.9970,43.0\n" +
+	"5,0,0,60,40,-88.9960,43.0\n"
+
+// TestPitchKeyframes checks interpolation by waypoint index and by distance
+func TestPitchKeyframes(t *testing.T) {
+	tests := []struct {
+		name      string
+		unit      string
+		keyframes []fp2lm.PitchKeyframe
+		expected  []string
+	}{
+		{
+			"Index ramp",
+			"index",
+			[]fp2lm.PitchKeyframe{{Position: 4, Pitch: -30}, {Position: 0, Pitch: -90}},
+			[]string{"-90.0", "-75.0", "-60.0", "-45.0", "-30.0"},
+		},
+		{
+			"Index hold before and after",
+			"",
+			[]fp2lm.PitchKeyframe{{Position: 1, Pitch: -80}, {Position: 3, Pitch: -40}},
+			[]string{"-80.0", "-80.0", "-60.0", "-40.0", "-40.0"},
+		},
+		{
+			"Distance ramp",
+			"distance",
+			[]fp2lm.PitchKeyframe{{Position: 0, Pitch: -90}, {Position: 163.1, Pitch: -10}},
+			[]string{"-90.0", "-50.0", "-10.0", "-10.0", "-10.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := fp2lm.DefaultOptions()
+			options.PitchKeyframes = tt.keyframes
+			options.PitchKeyframeUnit = tt.unit
+
+			var out bytes.Buffer
+			if err := fp2lm.Process(strings.NewReader(eastboundInput), &out, options); err != nil {
+				t.Fatalf("Process returned error: %v", err)
+			}
+
+			rows := outputRows(t, out.String())
+			for i, row := range rows {
+				if row[7] != tt.expected[i] {
+					t.Errorf("waypoint %d: expected pitch %s, got %s", i, tt.expected[i], row[7])
+				}
+				if row[6] != "2" {
+					t.Errorf("waypoint %d: expected gimbal mode 2, got %s", i, row[6])
+				}
+			}
+		})
+	}
+}
+
+// TestPitchKeyframesInvalid checks that out of range keyframes are rejected
+func TestPitchKeyframesInvalid(t *testing.T) {
+	options := fp2lm.DefaultOptions()
+	options.PitchKeyframes = []fp2lm.PitchKeyframe{{Position: 0, Pitch: 20}}
+
+	var out bytes.Buffer
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &out, options); err == nil {
+		t.Error("expected an error for a positive keyframe pitch")
+	}
+}
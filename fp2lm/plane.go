@@ -0,0 +1,40 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"math"
+)
+
+// LocalPlane projects geographic coordinates onto a flat east/north plane in meters
+// centred on an origin, using an equirectangular approximation that is accurate
+// enough for mission-sized areas
+type LocalPlane struct {
+	originLat float64
+	originLon float64
+	cosLat    float64
+}
+
+// NewLocalPlane creates a local plane centred on the given origin in decimal degrees
+func NewLocalPlane(latitude, longitude float64) LocalPlane {
+	return LocalPlane{originLat: latitude, originLon: longitude, cosLat: math.Cos(latitude * math.Pi / 180)}
+}
+
+// newWaypointPlane creates a local plane centred on the first waypoint
+func newWaypointPlane(waypoints []*missioncsv.LitchiWaypoint) LocalPlane {
+	if len(waypoints) == 0 {
+		return NewLocalPlane(0, 0)
+	}
+	return NewLocalPlane(waypoints[0].Point.Latitude, waypoints[0].Point.Longitude)
+}
+
+// ToXY converts a coordinate to east (x) and north (y) offsets from the origin in meters
+func (p LocalPlane) ToXY(latitude, longitude float64) (x, y float64) {
+	x = (longitude - p.originLon) * math.Pi / 180 * earthRadius * p.cosLat
+	y = (latitude - p.originLat) * math.Pi / 180 * earthRadius
+	return x, y
+}
+
+// ToLatLon converts east (x) and north (y) offsets from the origin back to a coordinate
+func (p LocalPlane) ToLatLon(x, y float64) (latitude, longitude float64) {
+	return p.originLat + y/earthRadius*180/math.Pi, p.originLon + x/(earthRadius*p.cosLat)*180/math.Pi
+}
@@ -0,0 +1,46 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"math"
+)
+
+// Gimbal pitch limits in degrees supported by Litchi when aiming at a point of interest
+const (
+	minPOIPitch = -90
+	maxPOIPitch = 30
+)
+
+// applyPOI aims the gimbal of every waypoint at the point of interest. Gimbal pitch is
+// set to the angle from the waypoint's altitude down (or up) to the POI's altitude over
+// the horizontal distance between them. Headings are left to the HeadingStrategy.
+//
+// The POI altitude is in poiAltMode, which is written to every waypoint. Waypoints in
+// another mode are compared with the POI above sea level, with relative altitudes
+// measured from takeoffElevation.
+func applyPOI(waypoints []*missioncsv.LitchiWaypoint, poi missioncsv.POI, poiAltMode int8, takeoffElevation float64) {
+	poiAltitude := poi.Altitude
+	if poiAltMode == 1 {
+		poiAltitude += takeoffElevation
+	}
+
+	for _, wp := range waypoints {
+		wp.POI = poi
+		wp.POIAltMode = poiAltMode
+		wp.GimbalMode = 1 // Focus POI
+
+		distance := Distance(wp.Point.Latitude, wp.Point.Longitude, poi.Latitude, poi.Longitude)
+		pitch := math.Atan2(poiAltitude-altitudeASL(wp, takeoffElevation), distance) * 180 / math.Pi
+		wp.GimbalPitch = float32(math.Max(minPOIPitch, math.Min(maxPOIPitch, pitch)))
+	}
+}
+
+// mixedAltitudeModes reports whether any waypoint's altitude mode differs from mode
+func mixedAltitudeModes(waypoints []*missioncsv.LitchiWaypoint, mode int8) bool {
+	for _, wp := range waypoints {
+		if wp.AltitudeMode != mode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"strings"
+)
+
+// RangeReport describes how far a mission strays from its home point
+type RangeReport struct {
+	// Farthest is the index (from 0) of the waypoint farthest from home
+	Farthest int
+
+	// FarthestDistance is the horizontal distance in meters of that waypoint from home
+	FarthestDistance float64
+
+	// Outside holds the indices of waypoints beyond the maximum distance
+	Outside []int
+
+	// LegsOutside holds the indices of the waypoints starting legs that go beyond the
+	// maximum distance
+	LegsOutside []int
+}
+
+// CheckRange measures the horizontal distance of every waypoint from home and finds
+// the waypoints and legs beyond a maximum distance, such as the limit for visual line
+// of sight or the aircraft's distance limit
+//
+// Parameters:
+//   - waypoints: The mission waypoints in flight order
+//   - home: The takeoff point; its altitude is ignored
+//   - maxDistance: The largest allowed distance from home in meters, or 0 for no limit
+//
+// The allowed area is a circle around home, so a straight leg can only leave it if
+// one of its ends does; those legs are reported in LegsOutside.
+func CheckRange(waypoints []*missioncsv.LitchiWaypoint, home missioncsv.Point, maxDistance float64) RangeReport {
+	var report RangeReport
+	outside := make([]bool, len(waypoints))
+	for i, wp := range waypoints {
+		distance := Distance(home.Latitude, home.Longitude, wp.Point.Latitude, wp.Point.Longitude)
+		if i == 0 || distance > report.FarthestDistance {
+			report.Farthest, report.FarthestDistance = i, distance
+		}
+		if maxDistance > 0 && distance > maxDistance {
+			outside[i] = true
+			report.Outside = append(report.Outside, i)
+		}
+	}
+	for i := 0; i+1 < len(waypoints); i++ {
+		if outside[i] || outside[i+1] {
+			report.LegsOutside = append(report.LegsOutside, i)
+		}
+	}
+	return report
+}
+
+// rangeError describes the waypoints of a report that are beyond maxDistance
+func rangeError(report RangeReport, maxDistance float64) error {
+	numbers := make([]string, len(report.Outside))
+	for i, index := range report.Outside {
+		numbers[i] = fmt.Sprint(index + 1)
+	}
+	subject := "waypoints " + strings.Join(numbers, ", ") + " are"
+	if len(numbers) == 1 {
+		subject = "waypoint " + numbers[0] + " is"
+	}
+	return fmt.Errorf("%s farther than %.1f m from home; the farthest, waypoint %d, is %.1f m away",
+		subject, maxDistance, report.Farthest+1, report.FarthestDistance)
+}
@@ -0,0 +1,61 @@
+package fp2lm_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCheckRange checks the farthest waypoint and the waypoints and legs beyond the limit
+func TestCheckRange(t *testing.T) {
+	// Waypoints about 111, 334, 222 and 556 m north of home
+	waypoints := route([2]float64{0.001, 0}, [2]float64{0.003, 0}, [2]float64{0.002, 0}, [2]float64{0.005, 0})
+	home := missioncsv.Point{}
+
+	report := fp2lm.CheckRange(waypoints, home, 300)
+	if report.Farthest != 3 || math.Abs(report.FarthestDistance-fp2lm.Distance(0, 0, 0.005, 0)) > 1e-6 {
+		t.Errorf("expected waypoint 3 to be farthest, got %d at %.1f m", report.Farthest, report.FarthestDistance)
+	}
+	if !reflect.DeepEqual(report.Outside, []int{1, 3}) {
+		t.Errorf("expected waypoints 1 and 3 outside, got %v", report.Outside)
+	}
+	if !reflect.DeepEqual(report.LegsOutside, []int{0, 1, 2}) {
+		t.Errorf("expected every leg to go outside, got %v", report.LegsOutside)
+	}
+
+	if report := fp2lm.CheckRange(waypoints, home, 0); len(report.Outside) != 0 || report.Farthest != 3 {
+		t.Errorf("expected no limit, got %+v", report)
+	}
+}
+
+// TestProcessWithMaxDistance checks that Process rejects waypoints beyond the limit
+func TestProcessWithMaxDistance(t *testing.T) {
+	options := fp2lm.DefaultOptions()
+	options.Home = &missioncsv.Point{Latitude: 43, Longitude: -89}
+	options.MaxDistance = 250
+
+	var output strings.Builder
+	err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options)
+	if err == nil || !strings.Contains(err.Error(), "waypoint 5 is farther than 250.0 m from home; the farthest, waypoint 5") {
+		t.Errorf("expected a range error, got %v", err)
+	}
+
+	options.MaxDistance = 150
+	err = fp2lm.Process(strings.NewReader(eastboundInput), &output, options)
+	if err == nil || !strings.Contains(err.Error(), "waypoints 3, 4, 5 are farther") {
+		t.Errorf("expected a range error listing three waypoints, got %v", err)
+	}
+
+	options.MaxDistance = 400
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options); err != nil {
+		t.Errorf("expected the mission to fit within 400 m, got %v", err)
+	}
+
+	options.Home = nil
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options); err == nil {
+		t.Error("expected an error for a distance limit without a home point")
+	}
+}
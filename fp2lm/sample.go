@@ -0,0 +1,150 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+)
+
+// RouteSample is a point along the route with its planned altitude above sea level
+type RouteSample struct {
+	// Leg is the index (from 0) of the waypoint starting the leg the sample is on
+	Leg int
+
+	// Waypoint is the index (from 0) of the waypoint at this point, or -1 for points
+	// sampled between waypoints
+	Waypoint int
+
+	// Distance is the distance in meters along the route
+	Distance float64
+
+	Latitude  float64
+	Longitude float64
+
+	// AltitudeASL is the planned altitude in meters above sea level
+	AltitudeASL float64
+}
+
+// SampleRoute spreads points evenly along the geodesic of every leg, no more than
+// step meters apart, including the waypoints themselves
+//
+// Both ends of each leg are converted to altitudes above sea level before the altitude
+// is interpolated linearly between them, so legs between waypoints with different
+// altitude modes are sampled correctly. Relative altitudes are measured from
+// takeoffElevation; see TakeoffElevation.
+func SampleRoute(waypoints []*missioncsv.LitchiWaypoint, takeoffElevation, step float64) ([]RouteSample, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("sample step must be positive, got %.1f", step)
+	}
+	if len(waypoints) == 0 {
+		return nil, nil
+	}
+
+	legs := MeasureRoute(waypoints).Legs
+	samples := []RouteSample{}
+	var travelled float64
+	for i, start := range waypoints {
+		// The last waypoint ends the last leg
+		leg := i
+		if leg == len(legs) && leg > 0 {
+			leg--
+		}
+		startAltitude := altitudeASL(start, takeoffElevation)
+		samples = append(samples, RouteSample{
+			Leg:         leg,
+			Waypoint:    i,
+			Distance:    travelled,
+			Latitude:    start.Point.Latitude,
+			Longitude:   start.Point.Longitude,
+			AltitudeASL: startAltitude,
+		})
+		if i == len(legs) {
+			break
+		}
+
+		end, length := waypoints[i+1], legs[i]
+		endAltitude := altitudeASL(end, takeoffElevation)
+		count := int(math.Ceil(length / step))
+		for k := 1; k < count; k++ {
+			fraction := float64(k) / float64(count)
+			lat, lon := IntermediatePoint(start.Point.Latitude, start.Point.Longitude,
+				end.Point.Latitude, end.Point.Longitude, fraction)
+			samples = append(samples, RouteSample{
+				Leg:         i,
+				Waypoint:    -1,
+				Distance:    travelled + length*fraction,
+				Latitude:    lat,
+				Longitude:   lon,
+				AltitudeASL: startAltitude + (endAltitude-startAltitude)*fraction,
+			})
+		}
+		travelled += length
+	}
+	return samples, nil
+}
+
+// altitudeASL returns a waypoint's altitude above sea level, adding takeoffElevation
+// to relative altitudes
+func altitudeASL(wp *missioncsv.LitchiWaypoint, takeoffElevation float64) float64 {
+	if wp.AltitudeMode == 1 {
+		return wp.Point.Altitude + takeoffElevation
+	}
+	return wp.Point.Altitude
+}
+
+// TakeoffElevation returns the elevation in meters above sea level that the relative
+// altitudes of a mission are measured from
+//
+// Parameters:
+//   - waypoints: The mission waypoints
+//   - home: Optional takeoff point
+//   - terrain: Optional elevation model of the bare ground
+//   - surface: Optional elevation model including trees and buildings
+//
+// The takeoff point is home, or the first waypoint when there is no home. Its
+// elevation comes from the terrain model when there is one. Otherwise it comes from
+// the surface model, but only at a given home point: the surface under the first
+// waypoint is often a roof or tree canopy, which would make every relative altitude
+// seem higher than it is. An error is returned when the mission has relative
+// altitudes and there is neither a terrain model nor a home point and surface model.
+// Missions with only absolute altitudes need no takeoff elevation and return 0.
+func TakeoffElevation(waypoints []*missioncsv.LitchiWaypoint, home *missioncsv.Point, terrain, surface ElevationModel) (float64, error) {
+	relative := false
+	for _, wp := range waypoints {
+		if wp.AltitudeMode == 1 {
+			relative = true
+			break
+		}
+	}
+	if !relative {
+		return 0, nil
+	}
+	return findTakeoffElevation(waypoints, home, terrain, surface)
+}
+
+// findTakeoffElevation looks up the takeoff elevation as TakeoffElevation does, even
+// when no waypoint has a relative altitude
+func findTakeoffElevation(waypoints []*missioncsv.LitchiWaypoint, home *missioncsv.Point, terrain, surface ElevationModel) (float64, error) {
+	var takeoff missioncsv.Point
+	switch {
+	case home != nil:
+		takeoff = *home
+	case len(waypoints) > 0 && terrain != nil:
+		takeoff = waypoints[0].Point
+	default:
+		return 0, fmt.Errorf("relative altitudes need a home point or a terrain model to find the takeoff elevation")
+	}
+
+	model := terrain
+	if model == nil {
+		model = surface
+	}
+	if model == nil {
+		return 0, fmt.Errorf("relative altitudes need a terrain or surface model to find the takeoff elevation")
+	}
+	elevation, err := model.Elevation(takeoff.Latitude, takeoff.Longitude)
+	if err != nil {
+		return 0, fmt.Errorf("takeoff point: %w", err)
+	}
+	return elevation, nil
+}
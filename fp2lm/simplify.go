@@ -0,0 +1,121 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"math"
+)
+
+// Simplify removes redundant waypoints, such as those Flight Planner places along
+// straight segments, to save Litchi's waypoint budget
+//
+// Parameters:
+//   - waypoints: The mission waypoints in flight order
+//   - tolerance: The largest distance in meters, combining horizontal and vertical
+//     offsets, that a removed waypoint may lie from the simplified path
+//
+// Returns:
+//   - The remaining waypoints, in order
+//   - The number of waypoints removed
+//
+// Waypoints are removed with the Douglas–Peucker algorithm in three dimensions, so
+// points that are collinear in plan but change altitude are kept. The first and last
+// waypoints, turns of 30 degrees or more, and any waypoint with actions other than the
+// default single photo are always kept.
+func Simplify(waypoints []*missioncsv.LitchiWaypoint, tolerance float64) ([]*missioncsv.LitchiWaypoint, int) {
+	if len(waypoints) < 3 || tolerance <= 0 {
+		return waypoints, 0
+	}
+
+	plane := newWaypointPlane(waypoints)
+	points := make([][3]float64, len(waypoints))
+	for i, wp := range waypoints {
+		x, y := plane.ToXY(wp.Point.Latitude, wp.Point.Longitude)
+		points[i] = [3]float64{x, y, wp.Point.Altitude}
+	}
+
+	keep := make([]bool, len(waypoints))
+	keep[0] = true
+	keep[len(waypoints)-1] = true
+	for i := range cornerWaypoints(waypoints) {
+		keep[i] = true
+	}
+	for i, wp := range waypoints {
+		if !hasDefaultActions(wp) {
+			keep[i] = true
+		}
+	}
+
+	// Simplify each run between waypoints that must be kept
+	start := 0
+	for end := 1; end < len(waypoints); end++ {
+		if keep[end] {
+			douglasPeucker(points, start, end, tolerance, keep)
+			start = end
+		}
+	}
+
+	var simplified []*missioncsv.LitchiWaypoint
+	for i, wp := range waypoints {
+		if keep[i] {
+			simplified = append(simplified, wp)
+		}
+	}
+	return simplified, len(waypoints) - len(simplified)
+}
+
+// douglasPeucker marks the points between start and end that must be kept for the
+// path to stay within tolerance of the original
+func douglasPeucker(points [][3]float64, start, end int, tolerance float64, keep []bool) {
+	if end-start < 2 {
+		return
+	}
+
+	farthest, farthestDist := -1, tolerance
+	for i := start + 1; i < end; i++ {
+		if d := segmentDistance(points[i], points[start], points[end]); d > farthestDist {
+			farthest, farthestDist = i, d
+		}
+	}
+	if farthest < 0 {
+		return
+	}
+
+	keep[farthest] = true
+	douglasPeucker(points, start, farthest, tolerance, keep)
+	douglasPeucker(points, farthest, end, tolerance, keep)
+}
+
+// segmentDistance returns the distance from p to the segment from a to b
+func segmentDistance(p, a, b [3]float64) float64 {
+	var ab, ap [3]float64
+	var lengthSq, dot float64
+	for i := 0; i < 3; i++ {
+		ab[i] = b[i] - a[i]
+		ap[i] = p[i] - a[i]
+		lengthSq += ab[i] * ab[i]
+		dot += ab[i] * ap[i]
+	}
+
+	t := 0.0
+	if lengthSq > 0 {
+		t = math.Max(0, math.Min(1, dot/lengthSq))
+	}
+	var distSq float64
+	for i := 0; i < 3; i++ {
+		d := ap[i] - t*ab[i]
+		distSq += d * d
+	}
+	return math.Sqrt(distSq)
+}
+
+// hasDefaultActions reports whether a waypoint has no actions or only the default photo
+func hasDefaultActions(wp *missioncsv.LitchiWaypoint) bool {
+	switch len(wp.Actions) {
+	case 0:
+		return true
+	case 1:
+		return wp.Actions[0] == missioncsv.Action{Type: missioncsv.ActionTakePhoto, Param: 0}
+	default:
+		return false
+	}
+}
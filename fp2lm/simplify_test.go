@@ -0,0 +1,108 @@
+package fp2lm_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"strings"
+	"testing"
+)
+
+// TestSimplify checks which waypoints are removed from a mostly straight route
+func TestSimplify(t *testing.T) {
+	// Eastbound legs of about 111 m climbing to 40 m and back, then a turn north
+	waypoints := route(
+		[2]float64{0, 0},
+		[2]float64{0.000005, 0.001}, // about 0.6 m off the line: removed
+		[2]float64{0, 0.002},        // top of the climb: kept
+		[2]float64{0, 0.003},        // halfway down: removed
+		[2]float64{0, 0.004},        // hovers: kept
+		[2]float64{0, 0.005},        // straight on: removed
+		[2]float64{0, 0.006},        // turns north: kept
+		[2]float64{0.001, 0.006},
+	)
+	for i, altitude := range []float64{30, 35, 40, 35, 30, 30, 30, 30} {
+		waypoints[i].Point.Altitude = altitude
+	}
+	waypoints[4].Actions = append(waypoints[4].Actions, missioncsv.Action{Type: missioncsv.ActionStay, Param: 2000})
+
+	simplified, removed := fp2lm.Simplify(waypoints, 1)
+	if removed != 3 {
+		t.Errorf("expected 3 waypoints removed, got %d", removed)
+	}
+
+	expected := []*missioncsv.LitchiWaypoint{waypoints[0], waypoints[2], waypoints[4], waypoints[6], waypoints[7]}
+	if len(simplified) != len(expected) {
+		t.Fatalf("expected %d waypoints, got %d", len(expected), len(simplified))
+	}
+	for i := range expected {
+		if simplified[i] != expected[i] {
+			t.Errorf("waypoint %d: expected %.4f, %.4f, got %.4f, %.4f", i,
+				expected[i].Point.Latitude, expected[i].Point.Longitude,
+				simplified[i].Point.Latitude, simplified[i].Point.Longitude)
+		}
+	}
+}
+
+// TestSimplifyTolerance checks that points further off the line than the tolerance are kept
+func TestSimplifyTolerance(t *testing.T) {
+	waypoints := route([2]float64{0, 0}, [2]float64{0.00005, 0.001}, [2]float64{0, 0.002})
+
+	if _, removed := fp2lm.Simplify(waypoints, 1); removed != 0 {
+		t.Errorf("expected a point 5.6 m off the line to be kept, %d removed", removed)
+	}
+	if _, removed := fp2lm.Simplify(waypoints, 10); removed != 1 {
+		t.Errorf("expected a point 5.6 m off the line to be removed at 10 m, %d removed", removed)
+	}
+}
+
+// TestProcessWithSimplify checks that Process drops waypoints on a straight line
+func TestProcessWithSimplify(t *testing.T) {
+	options := fp2lm.DefaultOptions()
+	options.SimplifyTolerance = 1
+
+	var output strings.Builder
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options); err != nil {
+		t.Fatalf("Process returned error: %v", err)
+	}
+
+	rows := outputRows(t, output.String())
+	if len(rows) != 2 {
+		t.Errorf("expected the straight route to reduce to 2 waypoints, got %d", len(rows))
+	}
+}
+
+// TestProcessSimplifyWithTemplate checks that template actions and index keyframes are
+// placed on the waypoints as planned, and that waypoints with actions survive
+// simplification
+func TestProcessSimplifyWithTemplate(t *testing.T) {
+	options := fp2lm.DefaultOptions()
+	options.SimplifyTolerance = 1
+	options.ActionTemplate = "stay 1s + photo @ 3; rotate @ last"
+	options.PitchKeyframes = []fp2lm.PitchKeyframe{{Position: 0, Pitch: -90}, {Position: 4, Pitch: -30}}
+
+	var output strings.Builder
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &output, options); err != nil {
+		t.Fatalf("Process returned error: %v", err)
+	}
+
+	rows := outputRows(t, output.String())
+	if len(rows) != 3 {
+		t.Fatalf("expected waypoints 1, 3 and 5 to remain, got %d", len(rows))
+	}
+	expected := []struct {
+		longitude, pitch, actions string
+	}{
+		{"-89.0000000", "-90.0", ""},
+		{"-88.9980000", "-60.0", "0,1000,1,0"},
+		{"-88.9960000", "-30.0", "4,90"},
+	}
+	for i, want := range expected {
+		row := rows[i]
+		if row[1] != want.longitude || row[7] != want.pitch {
+			t.Errorf("row %d: expected longitude %s and pitch %s, got %s and %s", i, want.longitude, want.pitch, row[1], row[7])
+		}
+		if actions := strings.Join(row[8:38], ","); !strings.HasPrefix(actions, want.actions) {
+			t.Errorf("row %d: expected actions starting %s, got %s", i, want.actions, actions)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"log/slog"
+	"math"
+)
+
+// Litchi speed limits in meters per second. A speed of 0 means the mission's cruise
+// speed, so the slowest planned speed is the smallest value the CSV can express.
+const (
+	minPlannedSpeed = 0.1
+	maxPlannedSpeed = 15
+)
+
+// defaultMaxBlur is the motion blur limit in pixels used when Camera.MaxBlur is zero
+const defaultMaxBlur = 1.0
+
+// Camera describes the shooting limits used to plan waypoint speeds
+type Camera struct {
+	// MinShotInterval is the shortest time in seconds the camera needs between photos
+	MinShotInterval float64
+
+	// ShutterSpeed is the exposure time in seconds, for example 0.002 for 1/500 s.
+	// Together with GSD it limits speed to keep motion blur acceptable.
+	ShutterSpeed float64
+
+	// GSD is the ground sample distance in meters per pixel at the mission altitude
+	GSD float64
+
+	// MaxBlur is the largest acceptable motion blur in pixels (1 when zero)
+	MaxBlur float64
+}
+
+// validate checks that the camera limits can be used to plan a speed
+func (c Camera) validate() error {
+	if c.MinShotInterval < 0 || c.ShutterSpeed < 0 || c.GSD < 0 || c.MaxBlur < 0 {
+		return fmt.Errorf("camera shot interval, shutter speed, GSD and blur limit must not be negative")
+	}
+	if c.MinShotInterval == 0 && (c.ShutterSpeed == 0 || c.GSD == 0) {
+		return fmt.Errorf("camera needs a minimum shot interval, or a shutter speed and GSD, to plan speeds")
+	}
+	return nil
+}
+
+// PlanSpeed computes the fastest safe speed in meters per second for taking a photo
+// every interval meters with the given camera
+//
+// The speed is limited so that:
+//   - The aircraft covers interval meters in no less than camera.MinShotInterval
+//     seconds (ignored when the interval or MinShotInterval is zero)
+//   - Motion blur during the exposure, speed * ShutterSpeed / GSD, stays within
+//     MaxBlur pixels (ignored when ShutterSpeed or GSD is zero)
+//   - The speed does not exceed Litchi's 15 m/s maximum
+//
+// The result is rounded down to the 0.1 m/s resolution of the Litchi CSV. When
+// neither limit applies, such as a waypoint without a photo interval and a camera
+// without a blur limit, 0 is returned so the waypoint flies at the cruise speed.
+// When the constraints require a speed below 0.1 m/s the interval cannot be flown;
+// that minimum speed is returned together with an error describing the problem.
+func PlanSpeed(interval float64, camera Camera) (float64, error) {
+	if err := camera.validate(); err != nil {
+		return 0, err
+	}
+
+	speed := float64(maxPlannedSpeed)
+	limited := false
+	if interval > 0 && camera.MinShotInterval > 0 {
+		speed = math.Min(speed, interval/camera.MinShotInterval)
+		limited = true
+	}
+	if camera.ShutterSpeed > 0 && camera.GSD > 0 {
+		maxBlur := camera.MaxBlur
+		if maxBlur == 0 {
+			maxBlur = defaultMaxBlur
+		}
+		speed = math.Min(speed, maxBlur*camera.GSD/camera.ShutterSpeed)
+		limited = true
+	}
+	if !limited {
+		return 0, nil
+	}
+
+	// Round down so the written value never exceeds the limit
+	speed = math.Floor(speed*10+1e-9) / 10
+	if speed < minPlannedSpeed {
+		return minPlannedSpeed, fmt.Errorf("photo interval of %.2f m needs a speed below the %.1f m/s minimum",
+			interval, minPlannedSpeed)
+	}
+	return speed, nil
+}
+
+// applySpeedPlan sets every waypoint's speed from its photo distance interval,
+// warning once for each interval that cannot be flown
+func applySpeedPlan(waypoints []*missioncsv.LitchiWaypoint, camera Camera) {
+	warned := map[float32]bool{}
+	for i, wp := range waypoints {
+		interval := wp.PhotoDistInterval
+		speed, err := PlanSpeed(math.Max(float64(interval), 0), camera)
+		if err != nil && !warned[interval] {
+			slog.Warn("Requested photo interval is physically impossible; photos will be missed",
+				"waypoint", i+1, "error", err)
+			warned[interval] = true
+		}
+		wp.Speed = float32(speed)
+	}
+}
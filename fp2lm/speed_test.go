@@ -0,0 +1,60 @@
+package fp2lm_test
+
+import (
+	"bytes"
+	"flightplan2litchimission/fp2lm"
+	"strings"
+	"testing"
+)
+
+// TestPlanSpeed checks the shot interval, motion blur and Litchi limits
+func TestPlanSpeed(t *testing.T) {
+	tests := []struct {
+		name        string
+		interval    float64
+		camera      fp2lm.Camera
+		expected    float64
+		expectError bool
+	}{
+		{"Shot interval", 20, fp2lm.Camera{MinShotInterval: 2}, 10, false},
+		{"Rounded down", 10, fp2lm.Camera{MinShotInterval: 3}, 3.3, false},
+		{"Motion blur", 20, fp2lm.Camera{MinShotInterval: 2, ShutterSpeed: 0.002, GSD: 0.02, MaxBlur: 0.5}, 5, false},
+		{"Blur only", 0, fp2lm.Camera{ShutterSpeed: 0.001, GSD: 0.01}, 10, false},
+		{"Litchi maximum", 100, fp2lm.Camera{MinShotInterval: 2}, 15, false},
+		{"No photo interval", 0, fp2lm.Camera{MinShotInterval: 2}, 0, false},
+		{"Impossible interval", 0.1, fp2lm.Camera{MinShotInterval: 2}, 0.1, true},
+		{"No usable limit", 20, fp2lm.Camera{ShutterSpeed: 0.002}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			speed, err := fp2lm.PlanSpeed(tt.interval, tt.camera)
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			} else if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if speed != tt.expected {
+				t.Errorf("Expected speed %.1f, got %.1f", tt.expected, speed)
+			}
+		})
+	}
+}
+
+// TestProcessWithCamera checks that planned speeds are written to every waypoint
+func TestProcessWithCamera(t *testing.T) {
+	options := fp2lm.DefaultOptions()
+	options.PhotoInterval = 12
+	options.Camera = &fp2lm.Camera{MinShotInterval: 2}
+
+	var out bytes.Buffer
+	if err := fp2lm.Process(strings.NewReader(eastboundInput), &out, options); err != nil {
+		t.Fatalf("Process returned error: %v", err)
+	}
+
+	for i, row := range outputRows(t, out.String()) {
+		if row[39] != "6.0" {
+			t.Errorf("waypoint %d: expected speed 6.0, got %s", i, row[39])
+		}
+	}
+}
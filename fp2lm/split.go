@@ -0,0 +1,98 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+)
+
+// SplitByBattery breaks a mission into parts that can each be flown on one battery
+//
+// Parameters:
+//   - waypoints: The converted mission waypoints in flight order
+//   - home: The takeoff and landing point, with Altitude in the same mode as the
+//     waypoint altitudes: 0 for relative altitudes, or the elevation above sea level
+//     of home for absolute ones. See HomeAltitude.
+//   - profile: The performance and endurance of the aircraft
+//
+// Every part is flown from home: out to its first waypoint at cruise speed, along its
+// waypoints as estimated by EstimateFlight, and back home again. Parts are made as
+// long as possible while that whole flight fits within the profile's Endurance, and
+// never longer than missioncsv.MaxLitchiWaypoints. Each part after the first starts
+// with a copy of the waypoint that ended the previous one, so the route continues
+// without a gap. An error is returned if a waypoint or leg is too far from home to be
+// flown on a single battery.
+func SplitByBattery(waypoints []*missioncsv.LitchiWaypoint, home missioncsv.Point, profile AircraftProfile) ([][]*missioncsv.LitchiWaypoint, error) {
+	if err := profile.validate(); err != nil {
+		return nil, err
+	}
+	if len(waypoints) == 0 {
+		return nil, nil
+	}
+
+	budget := profile.Endurance.Seconds()
+	fits := func(start, end int) bool {
+		seconds, _ := flightSeconds(waypoints[start:end+1], profile)
+		seconds += transitSeconds(home, waypoints[start].Point, profile)
+		seconds += transitSeconds(waypoints[end].Point, home, profile)
+		return seconds <= budget
+	}
+
+	if len(waypoints) == 1 {
+		if !fits(0, 0) {
+			return nil, fmt.Errorf("waypoint 1 is too far from home to reach on one battery")
+		}
+		return [][]*missioncsv.LitchiWaypoint{waypoints}, nil
+	}
+
+	var parts [][]*missioncsv.LitchiWaypoint
+	for start := 0; start < len(waypoints)-1; {
+		end := start
+		for end+1 < len(waypoints) && end+1-start < missioncsv.MaxLitchiWaypoints && fits(start, end+1) {
+			end++
+		}
+		if end == start {
+			return nil, fmt.Errorf("the leg from waypoint %d to %d cannot be flown from home on one battery",
+				start+1, start+2)
+		}
+
+		part := make([]*missioncsv.LitchiWaypoint, end-start+1)
+		copy(part, waypoints[start:end+1])
+		if len(parts) > 0 {
+			// The previous part ended here, so give this part its own copy
+			first := *part[0]
+			part[0] = &first
+		}
+		parts = append(parts, part)
+		start = end
+	}
+	return parts, nil
+}
+
+// HomeAltitude returns the altitude of home in the mode of the mission's waypoints, as
+// SplitByBattery expects, for when it is not known
+//
+// Missions whose first waypoint is relative are flown from 0 m. For absolute
+// missions the terrain elevation at home is used when terrain is set, and otherwise
+// the first waypoint's altitude, so that the transit is estimated without a climb.
+// An error is returned if terrain has no elevation at home.
+func HomeAltitude(waypoints []*missioncsv.LitchiWaypoint, home missioncsv.Point, terrain ElevationModel) (float64, error) {
+	if len(waypoints) == 0 || waypoints[0].AltitudeMode == 1 {
+		return 0, nil
+	}
+	if terrain != nil {
+		elevation, err := terrain.Elevation(home.Latitude, home.Longitude)
+		if err != nil {
+			return 0, fmt.Errorf("home elevation: %w", err)
+		}
+		return elevation, nil
+	}
+	return waypoints[0].Point.Altitude, nil
+}
+
+// transitSeconds returns the time in seconds to fly directly between two points at
+// cruise speed, starting and ending at a stop
+func transitSeconds(from, to missioncsv.Point, profile AircraftProfile) float64 {
+	distance := Distance(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+	return legDuration(&missioncsv.LitchiWaypoint{Point: from}, &missioncsv.LitchiWaypoint{Point: to},
+		distance, true, true, profile).Seconds()
+}
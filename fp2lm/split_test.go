@@ -0,0 +1,191 @@
+package fp2lm_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// serpentineRoute returns lines of waypoints flown back and forth east of the origin
+func serpentineRoute(lines, perLine int, spacing float64) []*missioncsv.LitchiWaypoint {
+	var coords [][2]float64
+	for line := 0; line < lines; line++ {
+		for i := 0; i < perLine; i++ {
+			column := i
+			if line%2 == 1 {
+				column = perLine - 1 - i
+			}
+			coords = append(coords, [2]float64{float64(line) * 0.001, float64(column) * spacing})
+		}
+	}
+	return route(coords...)
+}
+
+// roundTrip returns a part with bare waypoints at home added to both ends, so that
+// EstimateFlight includes the transit out and back
+func roundTrip(home missioncsv.Point, part []*missioncsv.LitchiWaypoint) []*missioncsv.LitchiWaypoint {
+	trip := []*missioncsv.LitchiWaypoint{{Point: home}}
+	trip = append(trip, part...)
+	return append(trip, &missioncsv.LitchiWaypoint{Point: home})
+}
+
+// TestSplitByBattery checks that every part fits a battery and the parts join up
+func TestSplitByBattery(t *testing.T) {
+	waypoints := serpentineRoute(4, 4, 0.003)
+	home := missioncsv.Point{Latitude: -0.0005, Longitude: 0}
+	profile := testAircraft
+	profile.Endurance = 300 * time.Second
+
+	parts, err := fp2lm.SplitByBattery(waypoints, home, profile)
+	if err != nil {
+		t.Fatalf("SplitByBattery returned error: %v", err)
+	}
+	if len(parts) < 2 {
+		t.Fatalf("expected the mission to need several batteries, got %d part", len(parts))
+	}
+
+	next := 0
+	for i, part := range parts {
+		estimate, err := fp2lm.EstimateFlight(roundTrip(home, part), profile)
+		if err != nil {
+			t.Fatalf("EstimateFlight returned error: %v", err)
+		}
+		if estimate.BatteryUse > 1 {
+			t.Errorf("part %d: expected to fit one battery, needs %.2f", i+1, estimate.BatteryUse)
+		}
+
+		// Each part should be as long as possible
+		if end := next + len(part) - 1; i < len(parts)-1 {
+			longer := append(append([]*missioncsv.LitchiWaypoint{}, part...), waypoints[end+1])
+			if estimate, _ := fp2lm.EstimateFlight(roundTrip(home, longer), profile); estimate.BatteryUse <= 1 {
+				t.Errorf("part %d: expected waypoint %d not to fit, uses %.2f", i+1, end+2, estimate.BatteryUse)
+			}
+		}
+
+		for j, wp := range part {
+			if wp.Point != waypoints[next+j].Point {
+				t.Errorf("part %d waypoint %d: expected waypoint %d", i+1, j, next+j+1)
+			}
+		}
+		if i > 0 && part[0] == parts[i-1][len(parts[i-1])-1] {
+			t.Errorf("part %d: expected its own copy of the shared waypoint", i+1)
+		}
+		next += len(part) - 1
+	}
+	if next != len(waypoints)-1 {
+		t.Errorf("expected the parts to end at waypoint %d, ended at %d", len(waypoints), next+1)
+	}
+}
+
+// TestSplitByBatteryWaypointLimit checks that parts stay within the Litchi waypoint limit
+func TestSplitByBatteryWaypointLimit(t *testing.T) {
+	waypoints := serpentineRoute(10, 15, 0.0001)
+	profile := testAircraft
+	profile.Endurance = 10 * time.Hour
+
+	parts, err := fp2lm.SplitByBattery(waypoints, missioncsv.Point{}, profile)
+	if err != nil {
+		t.Fatalf("SplitByBattery returned error: %v", err)
+	}
+	if len(parts) != 2 || len(parts[0]) != missioncsv.MaxLitchiWaypoints || len(parts[1]) != 52 {
+		var sizes []int
+		for _, part := range parts {
+			sizes = append(sizes, len(part))
+		}
+		t.Errorf("expected parts of 99 and 52 waypoints, got %v", sizes)
+	}
+}
+
+// TestSplitByBatteryTooFar checks that unreachable missions are rejected
+func TestSplitByBatteryTooFar(t *testing.T) {
+	waypoints := serpentineRoute(2, 2, 0.001)
+	far := missioncsv.Point{Latitude: -0.1, Longitude: 0}
+
+	if _, err := fp2lm.SplitByBattery(waypoints, far, testAircraft); err == nil {
+		t.Error("expected an error for a mission 11 km from home on a 100 s battery")
+	}
+	if _, err := fp2lm.SplitByBattery(waypoints[:1], far, testAircraft); err == nil {
+		t.Error("expected an error for a single waypoint 11 km from home")
+	}
+}
+
+// TestHomeAltitude checks the home altitude for relative and absolute missions
+func TestHomeAltitude(t *testing.T) {
+	home := missioncsv.Point{Latitude: -0.0005, Longitude: 0}
+	absolute := func() []*missioncsv.LitchiWaypoint {
+		waypoints := lShapedRoute()
+		for _, wp := range waypoints {
+			wp.AltitudeMode = 0
+			wp.Point.Altitude = 130
+		}
+		return waypoints
+	}
+
+	tests := []struct {
+		name      string
+		waypoints []*missioncsv.LitchiWaypoint
+		terrain   fp2lm.ElevationModel
+		expected  float64
+	}{
+		{"Relative", lShapedRoute(), hill{}, 0},
+		{"Absolute over terrain", absolute(), hill{}, 100},
+		{"Absolute without terrain", absolute(), nil, 130},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			altitude, err := fp2lm.HomeAltitude(tt.waypoints, home, tt.terrain)
+			if err != nil || altitude != tt.expected {
+				t.Errorf("expected %.1f, got %.1f (%v)", tt.expected, altitude, err)
+			}
+		})
+	}
+
+	if _, err := fp2lm.HomeAltitude(absolute(), home, noData{}); err == nil {
+		t.Error("expected an error without terrain data at home")
+	}
+}
+
+// TestSplitByBatteryAbsolute checks that an absolute mission flown from the home
+// elevation splits like the same mission in relative altitudes
+func TestSplitByBatteryAbsolute(t *testing.T) {
+	relative := serpentineRoute(4, 4, 0.003)
+	absolute := serpentineRoute(4, 4, 0.003)
+	for i := range relative {
+		relative[i].Point.Altitude = 30
+		absolute[i].Point.Altitude = 130
+		absolute[i].AltitudeMode = 0
+	}
+	home := missioncsv.Point{Latitude: -0.0005, Longitude: 0}
+	profile := testAircraft
+	profile.Endurance = 300 * time.Second
+
+	sizes := func(waypoints []*missioncsv.LitchiWaypoint, home missioncsv.Point) []int {
+		parts, err := fp2lm.SplitByBattery(waypoints, home, profile)
+		if err != nil {
+			t.Fatalf("SplitByBattery returned error: %v", err)
+		}
+		var sizes []int
+		for _, part := range parts {
+			sizes = append(sizes, len(part))
+		}
+		return sizes
+	}
+
+	expected := sizes(relative, home)
+	altitude, err := fp2lm.HomeAltitude(absolute, home, hill{})
+	if err != nil {
+		t.Fatalf("HomeAltitude returned error: %v", err)
+	}
+	home.Altitude = altitude
+	if got := sizes(absolute, home); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected parts of %v waypoints, got %v", expected, got)
+	}
+
+	// Flying from sea level would add a 100 m climb to every part
+	home.Altitude = 0
+	if got := sizes(absolute, home); reflect.DeepEqual(got, expected) {
+		t.Errorf("expected a climb from sea level to shorten the parts, got %v", got)
+	}
+}
@@ -0,0 +1,222 @@
+package fp2lm
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+)
+
+// Litchi and DJI waypoint mission limits
+const (
+	minWaypointSpacing = 0.5
+	maxLegLength       = 2000
+	minMissionAltitude = -200
+	maxMissionAltitude = 500
+	maxMissionSpeed    = 15
+	minGimbalPitch     = -90
+	maxGimbalPitch     = 30
+)
+
+// Severity ranks how serious a validation finding is
+type Severity int
+
+const (
+	// SeverityWarning marks a mission that loads but may not fly as intended
+	SeverityWarning Severity = iota
+	// SeverityError marks a mission that Litchi will reject or cannot fly
+	SeverityError
+)
+
+// String returns the lowercase name of the severity
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Rule identifies the check that produced a finding
+type Rule string
+
+// Rules checked by Validate
+const (
+	RuleWaypointCount Rule = "waypoint-count"
+	RuleCoordinates   Rule = "coordinates"
+	RuleSpacing       Rule = "spacing"
+	RuleLegLength     Rule = "leg-length"
+	RuleAltitude      Rule = "altitude"
+	RuleSpeed         Rule = "speed"
+	RuleCurveSize     Rule = "curve-size"
+	RuleActions       Rule = "actions"
+	RuleGimbal        Rule = "gimbal"
+	RuleHeading       Rule = "heading"
+	RuleSettings      Rule = "settings"
+)
+
+// Finding is a single problem found by Validate
+type Finding struct {
+	// Severity ranks the problem
+	Severity Severity
+
+	// Rule identifies the check that failed
+	Rule Rule
+
+	// Waypoint is the index (from 0) of the waypoint concerned, or of the waypoint
+	// starting the leg concerned, or -1 for the mission as a whole
+	Waypoint int
+
+	// Message describes the problem
+	Message string
+}
+
+// String formats the finding with waypoints numbered from 1
+func (f Finding) String() string {
+	if f.Waypoint < 0 {
+		return fmt.Sprintf("%s [%s]: %s", f.Severity, f.Rule, f.Message)
+	}
+	return fmt.Sprintf("%s [%s] waypoint %d: %s", f.Severity, f.Rule, f.Waypoint+1, f.Message)
+}
+
+// ValidationOptions adds checks beyond Litchi's own limits
+type ValidationOptions struct {
+	// MaxAltitudeAGL warns about relative altitudes above this many meters, such as a
+	// regulatory ceiling. 0 disables the check.
+	MaxAltitudeAGL float64
+}
+
+// DefaultValidationOptions returns recommended default options for Validate
+//
+// The default options are:
+// - MaxAltitudeAGL: 120 meters
+func DefaultValidationOptions() *ValidationOptions {
+	return &ValidationOptions{
+		MaxAltitudeAGL: 120,
+	}
+}
+
+// Validate checks that a mission will load in Litchi and fly as planned
+//
+// Parameters:
+//   - waypoints: The mission waypoints in flight order
+//   - options: Additional checks to apply, nil for DefaultValidationOptions
+//
+// Returns:
+//   - Every problem found, in waypoint order after any whole-mission findings
+//
+// Errors are reported for more than missioncsv.MaxLitchiWaypoints waypoints,
+// coordinates that are out of range or NaN, waypoints closer than 0.5 m or legs
+// longer than 2000 m, altitudes that are not finite or outside -200 to 500 m, speeds outside 0 to 15 m/s, curve sizes outside 0 to 1000 m or larger
+// together than the leg between them, invalid actions, gimbal pitch outside -90 to
+// 30 degrees, headings outside -180 to 360 degrees, and mode settings Litchi does not
+// define. Altitudes above options.MaxAltitudeAGL in relative mode are warnings.
+func Validate(waypoints []*missioncsv.LitchiWaypoint, options *ValidationOptions) []Finding {
+	if options == nil {
+		options = DefaultValidationOptions()
+	}
+
+	var findings []Finding
+	add := func(severity Severity, rule Rule, waypoint int, format string, args ...interface{}) {
+		findings = append(findings, Finding{Severity: severity, Rule: rule, Waypoint: waypoint, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(waypoints) == 0 {
+		add(SeverityError, RuleWaypointCount, -1, "mission has no waypoints")
+	} else if len(waypoints) == 1 {
+		add(SeverityError, RuleWaypointCount, -1, "mission needs at least 2 waypoints, got 1")
+	}
+	if len(waypoints) > missioncsv.MaxLitchiWaypoints {
+		add(SeverityError, RuleWaypointCount, -1, "mission has %d waypoints, more than the Litchi limit of %d",
+			len(waypoints), missioncsv.MaxLitchiWaypoints)
+	}
+
+	for i, wp := range waypoints {
+		// NaN fails every range comparison, so it is checked explicitly
+		if math.IsNaN(wp.Point.Latitude) || math.IsNaN(wp.Point.Longitude) ||
+			wp.Point.Latitude < -90 || wp.Point.Latitude > 90 || wp.Point.Longitude < -180 || wp.Point.Longitude > 180 {
+			add(SeverityError, RuleCoordinates, i, "invalid coordinate %.7f, %.7f", wp.Point.Latitude, wp.Point.Longitude)
+		}
+
+		if math.IsNaN(wp.Point.Altitude) || math.IsInf(wp.Point.Altitude, 0) {
+			add(SeverityError, RuleAltitude, i, "altitude %.1f m is not a finite number", wp.Point.Altitude)
+		} else if wp.Point.Altitude < minMissionAltitude || wp.Point.Altitude > maxMissionAltitude {
+			add(SeverityError, RuleAltitude, i, "altitude %.1f m is outside %d to %d m",
+				wp.Point.Altitude, minMissionAltitude, maxMissionAltitude)
+		} else if wp.AltitudeMode == 1 && options.MaxAltitudeAGL > 0 && wp.Point.Altitude > options.MaxAltitudeAGL {
+			add(SeverityWarning, RuleAltitude, i, "altitude %.1f m is above the %.1f m limit",
+				wp.Point.Altitude, options.MaxAltitudeAGL)
+		}
+
+		if wp.Speed < 0 || wp.Speed > maxMissionSpeed {
+			add(SeverityError, RuleSpeed, i, "speed %.1f m/s is outside 0 to %d m/s", wp.Speed, maxMissionSpeed)
+		}
+
+		if wp.CurveSize < 0 || wp.CurveSize > maxCurveSize {
+			add(SeverityError, RuleCurveSize, i, "curve size %.1f m is outside 0 to %d m", wp.CurveSize, maxCurveSize)
+		}
+
+		if err := missioncsv.ValidateActions(wp.Actions); err != nil {
+			add(SeverityError, RuleActions, i, "%v", err)
+		}
+
+		if wp.GimbalPitch < minGimbalPitch || wp.GimbalPitch > maxGimbalPitch {
+			add(SeverityError, RuleGimbal, i, "gimbal pitch %.1f is outside %d to %d degrees",
+				wp.GimbalPitch, minGimbalPitch, maxGimbalPitch)
+		}
+		if wp.GimbalMode < 0 || wp.GimbalMode > 2 {
+			add(SeverityError, RuleGimbal, i, "gimbal mode must be 0, 1 or 2, got %d", wp.GimbalMode)
+		}
+
+		if wp.Heading < -180 || wp.Heading > 360 {
+			add(SeverityError, RuleHeading, i, "heading %.1f is outside -180 to 360 degrees", wp.Heading)
+		}
+
+		if wp.AltitudeMode != 0 && wp.AltitudeMode != 1 {
+			add(SeverityError, RuleSettings, i, "altitude mode must be 0 or 1, got %d", wp.AltitudeMode)
+		}
+		if wp.RotationDir != 0 && wp.RotationDir != 1 {
+			add(SeverityError, RuleSettings, i, "rotation direction must be 0 or 1, got %d", wp.RotationDir)
+		}
+		if wp.POIAltMode != 0 && wp.POIAltMode != 1 {
+			add(SeverityError, RuleSettings, i, "POI altitude mode must be 0 or 1, got %d", wp.POIAltMode)
+		}
+		if wp.PhotoTimeInterval != -1 && wp.PhotoTimeInterval < 0 {
+			add(SeverityError, RuleSettings, i, "photo time interval must be -1 or positive, got %.1f", wp.PhotoTimeInterval)
+		}
+		if wp.PhotoDistInterval != -1 && wp.PhotoDistInterval < 0 {
+			add(SeverityError, RuleSettings, i, "photo distance interval must be -1 or positive, got %.1f", wp.PhotoDistInterval)
+		}
+
+		if i == len(waypoints)-1 {
+			continue
+		}
+
+		// Check the leg to the next waypoint
+		next := waypoints[i+1]
+		leg := Distance(wp.Point.Latitude, wp.Point.Longitude, next.Point.Latitude, next.Point.Longitude)
+		switch {
+		case leg < minWaypointSpacing:
+			add(SeverityError, RuleSpacing, i, "waypoint is %.2f m from the next one, closer than %.1f m",
+				leg, minWaypointSpacing)
+		case leg > maxLegLength:
+			add(SeverityError, RuleLegLength, i, "leg to the next waypoint is %.1f m, longer than %d m",
+				leg, maxLegLength)
+		}
+
+		// Litchi rejects curves that would overlap on the leg between them
+		if curves := float64(wp.CurveSize) + float64(next.CurveSize); curves > leg && leg >= minWaypointSpacing {
+			add(SeverityError, RuleCurveSize, i, "curve sizes %.1f and %.1f m overlap on a leg of %.1f m",
+				wp.CurveSize, next.CurveSize, leg)
+		}
+	}
+	return findings
+}
+
+// HasErrors reports whether any finding is an error
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
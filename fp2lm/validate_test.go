@@ -0,0 +1,91 @@
+package fp2lm_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// TestValidate checks that each Litchi limit produces a finding at the right waypoint
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(waypoints []*missioncsv.LitchiWaypoint)
+		severity fp2lm.Severity
+		rule     fp2lm.Rule
+		waypoint int
+	}{
+		{"Too close", func(w []*missioncsv.LitchiWaypoint) { w[2].Point = w[1].Point }, fp2lm.SeverityError, fp2lm.RuleSpacing, 1},
+		{"Too far", func(w []*missioncsv.LitchiWaypoint) { w[2].Point.Latitude = 0.05 }, fp2lm.SeverityError, fp2lm.RuleLegLength, 1},
+		{"NaN latitude", func(w []*missioncsv.LitchiWaypoint) { w[1].Point.Latitude = math.NaN() }, fp2lm.SeverityError, fp2lm.RuleCoordinates, 1},
+		{"NaN longitude", func(w []*missioncsv.LitchiWaypoint) { w[0].Point.Longitude = math.NaN() }, fp2lm.SeverityError, fp2lm.RuleCoordinates, 0},
+		{"Too high", func(w []*missioncsv.LitchiWaypoint) { w[0].Point.Altitude = 501 }, fp2lm.SeverityError, fp2lm.RuleAltitude, 0},
+		{"NaN altitude", func(w []*missioncsv.LitchiWaypoint) { w[2].Point.Altitude = math.NaN() }, fp2lm.SeverityError, fp2lm.RuleAltitude, 2},
+		{"Infinite altitude", func(w []*missioncsv.LitchiWaypoint) { w[0].Point.Altitude = math.Inf(1) }, fp2lm.SeverityError, fp2lm.RuleAltitude, 0},
+		{"Above ceiling", func(w []*missioncsv.LitchiWaypoint) { w[1].Point.Altitude = 150 }, fp2lm.SeverityWarning, fp2lm.RuleAltitude, 1},
+		{"Too fast", func(w []*missioncsv.LitchiWaypoint) { w[1].Speed = 16 }, fp2lm.SeverityError, fp2lm.RuleSpeed, 1},
+		{"Overlapping curves", func(w []*missioncsv.LitchiWaypoint) { w[0].CurveSize, w[1].CurveSize = 600, 600 }, fp2lm.SeverityError, fp2lm.RuleCurveSize, 0},
+		{"Bad action", func(w []*missioncsv.LitchiWaypoint) {
+			w[2].Actions = []missioncsv.Action{{Type: missioncsv.ActionZoom, Param: 50}}
+		}, fp2lm.SeverityError, fp2lm.RuleActions, 2},
+		{"Gimbal up", func(w []*missioncsv.LitchiWaypoint) { w[1].GimbalPitch = 45 }, fp2lm.SeverityError, fp2lm.RuleGimbal, 1},
+		{"Heading", func(w []*missioncsv.LitchiWaypoint) { w[0].Heading = 400 }, fp2lm.SeverityError, fp2lm.RuleHeading, 0},
+		{"Altitude mode", func(w []*missioncsv.LitchiWaypoint) { w[2].AltitudeMode = 3 }, fp2lm.SeverityError, fp2lm.RuleSettings, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waypoints := lShapedRoute()
+			for _, wp := range waypoints {
+				wp.Point.Altitude = 60
+			}
+			if findings := fp2lm.Validate(waypoints, nil); len(findings) != 0 {
+				t.Fatalf("expected the unmodified route to be valid, got %v", findings)
+			}
+
+			tt.modify(waypoints)
+			findings := fp2lm.Validate(waypoints, nil)
+			if len(findings) != 1 {
+				t.Fatalf("expected 1 finding, got %v", findings)
+			}
+			f := findings[0]
+			if f.Severity != tt.severity || f.Rule != tt.rule || f.Waypoint != tt.waypoint {
+				t.Errorf("expected %s [%s] at waypoint %d, got %v", tt.severity, tt.rule, tt.waypoint+1, f)
+			}
+			if fp2lm.HasErrors(findings) != (tt.severity == fp2lm.SeverityError) {
+				t.Errorf("HasErrors disagrees with severity %s", tt.severity)
+			}
+		})
+	}
+}
+
+// TestValidateWaypointCount checks the whole-mission waypoint limits
+func TestValidateWaypointCount(t *testing.T) {
+	var coords [][2]float64
+	for i := 0; i <= missioncsv.MaxLitchiWaypoints; i++ {
+		coords = append(coords, [2]float64{0, float64(i) * 0.0001})
+	}
+
+	expected := []fp2lm.Finding{{
+		Severity: fp2lm.SeverityError,
+		Rule:     fp2lm.RuleWaypointCount,
+		Waypoint: -1,
+		Message:  "mission has 100 waypoints, more than the Litchi limit of 99",
+	}}
+	if findings := fp2lm.Validate(route(coords...), nil); !reflect.DeepEqual(findings, expected) {
+		t.Errorf("expected %v, got %v", expected, findings)
+	}
+
+	if findings := fp2lm.Validate(nil, nil); len(findings) != 1 || findings[0].Rule != fp2lm.RuleWaypointCount {
+		t.Errorf("expected an empty mission to be reported, got %v", findings)
+	}
+
+	// The altitude ceiling can be disabled
+	waypoints := lShapedRoute()
+	waypoints[0].Point.Altitude = 200
+	if findings := fp2lm.Validate(waypoints, &fp2lm.ValidationOptions{}); len(findings) != 0 {
+		t.Errorf("expected no findings without a ceiling, got %v", findings)
+	}
+}
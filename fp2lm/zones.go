@@ -0,0 +1,249 @@
+package fp2lm
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadZones reads geofence zones from a GeoJSON (.geojson or .json) or KML (.kml) file
+//
+// Every polygon in the file becomes a zone of the given kind. See ReadGeoJSONZones and
+// ReadKMLZones for how zones are named.
+func LoadZones(path string, kind ZoneKind) ([]Zone, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zones: %w", err)
+	}
+	defer file.Close()
+
+	var zones []Zone
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".geojson", ".json":
+		zones, err = ReadGeoJSONZones(file, kind)
+	case ".kml":
+		zones, err = ReadKMLZones(file, kind)
+	default:
+		return nil, fmt.Errorf("zones file %q must have a .geojson, .json or .kml extension", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return zones, nil
+}
+
+// geoJSONObject holds the members of any GeoJSON object used for zones
+type geoJSONObject struct {
+	Type        string                 `json:"type"`
+	Features    []geoJSONObject        `json:"features"`
+	Geometry    *geoJSONObject         `json:"geometry"`
+	Geometries  []geoJSONObject        `json:"geometries"`
+	Properties  map[string]interface{} `json:"properties"`
+	Coordinates json.RawMessage        `json:"coordinates"`
+}
+
+// ReadGeoJSONZones reads zones from a GeoJSON FeatureCollection, Feature or geometry
+//
+// Each Polygon, and each polygon of a MultiPolygon, becomes a zone of the given kind.
+// Zones are named from the feature's "name" property, or numbered when it has none.
+// Other geometry types are ignored.
+func ReadGeoJSONZones(r io.Reader, kind ZoneKind) ([]Zone, error) {
+	var root geoJSONObject
+	if err := json.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	var zones []Zone
+	var collect func(object geoJSONObject, name string) error
+	collect = func(object geoJSONObject, name string) error {
+		switch object.Type {
+		case "FeatureCollection":
+			for _, feature := range object.Features {
+				if err := collect(feature, ""); err != nil {
+					return err
+				}
+			}
+		case "Feature":
+			if object.Geometry != nil {
+				return collect(*object.Geometry, featureName(object.Properties))
+			}
+		case "GeometryCollection":
+			for _, geometry := range object.Geometries {
+				if err := collect(geometry, name); err != nil {
+					return err
+				}
+			}
+		case "Polygon":
+			var rings [][][]float64
+			if err := json.Unmarshal(object.Coordinates, &rings); err != nil {
+				return fmt.Errorf("invalid Polygon coordinates: %w", err)
+			}
+			zone, err := geoJSONZone(rings, name, kind, len(zones))
+			if err != nil {
+				return err
+			}
+			zones = append(zones, zone)
+		case "MultiPolygon":
+			var polygons [][][][]float64
+			if err := json.Unmarshal(object.Coordinates, &polygons); err != nil {
+				return fmt.Errorf("invalid MultiPolygon coordinates: %w", err)
+			}
+			for _, rings := range polygons {
+				zone, err := geoJSONZone(rings, name, kind, len(zones))
+				if err != nil {
+					return err
+				}
+				zones = append(zones, zone)
+			}
+		}
+		return nil
+	}
+
+	if err := collect(root, ""); err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+// featureName returns the "name" property of a GeoJSON feature, if it is a string
+func featureName(properties map[string]interface{}) string {
+	for _, key := range []string{"name", "Name", "NAME"} {
+		if name, ok := properties[key].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// geoJSONZone converts GeoJSON polygon rings, in longitude, latitude order, to a zone
+func geoJSONZone(rings [][][]float64, name string, kind ZoneKind, index int) (Zone, error) {
+	if name == "" {
+		name = fmt.Sprintf("zone %d", index+1)
+	}
+	zone := Zone{Name: name, Kind: kind}
+	for i, ring := range rings {
+		var vertices [][2]float64
+		for _, position := range ring {
+			if len(position) < 2 {
+				return Zone{}, fmt.Errorf("zone %q has a position with fewer than 2 coordinates", name)
+			}
+			vertices = append(vertices, [2]float64{position[1], position[0]})
+		}
+		if err := validateRing(vertices, name); err != nil {
+			return Zone{}, err
+		}
+		if i == 0 {
+			zone.Boundary = vertices
+		} else {
+			zone.Holes = append(zone.Holes, vertices)
+		}
+	}
+	if zone.Boundary == nil {
+		return Zone{}, fmt.Errorf("zone %q has no boundary", name)
+	}
+	return zone, nil
+}
+
+// kmlPlacemark holds the parts of a KML Placemark used for zones
+type kmlPlacemark struct {
+	Name     string       `xml:"name"`
+	Polygons []kmlPolygon `xml:"Polygon"`
+	Multi    []kmlPolygon `xml:"MultiGeometry>Polygon"`
+}
+
+// kmlPolygon holds the coordinate strings of a KML Polygon's rings
+type kmlPolygon struct {
+	Outer string   `xml:"outerBoundaryIs>LinearRing>coordinates"`
+	Inner []string `xml:"innerBoundaryIs>LinearRing>coordinates"`
+}
+
+// ReadKMLZones reads zones from the Polygons in a KML document's Placemarks
+//
+// Each Polygon, including those inside a MultiGeometry, becomes a zone of the given
+// kind named after its Placemark, or numbered when the Placemark has no name.
+// Placemarks may be nested in any Document or Folder.
+func ReadKMLZones(r io.Reader, kind ZoneKind) ([]Zone, error) {
+	decoder := xml.NewDecoder(r)
+	var zones []Zone
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid KML: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+		var placemark kmlPlacemark
+		if err := decoder.DecodeElement(&placemark, &start); err != nil {
+			return nil, fmt.Errorf("invalid KML: %w", err)
+		}
+
+		for _, polygon := range append(placemark.Polygons, placemark.Multi...) {
+			name := strings.TrimSpace(placemark.Name)
+			if name == "" {
+				name = fmt.Sprintf("zone %d", len(zones)+1)
+			}
+			zone := Zone{Name: name, Kind: kind}
+			if zone.Boundary, err = parseKMLCoordinates(polygon.Outer, name); err != nil {
+				return nil, err
+			}
+			for _, inner := range polygon.Inner {
+				hole, err := parseKMLCoordinates(inner, name)
+				if err != nil {
+					return nil, err
+				}
+				zone.Holes = append(zone.Holes, hole)
+			}
+			zones = append(zones, zone)
+		}
+	}
+	return zones, nil
+}
+
+// parseKMLCoordinates parses a KML coordinates string of whitespace-separated
+// "longitude,latitude[,altitude]" tuples into latitude, longitude pairs
+func parseKMLCoordinates(text, name string) ([][2]float64, error) {
+	var vertices [][2]float64
+	for _, tuple := range strings.Fields(text) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("zone %q has a malformed coordinate %q", name, tuple)
+		}
+		lon, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("zone %q has a malformed longitude %q", name, parts[0])
+		}
+		lat, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("zone %q has a malformed latitude %q", name, parts[1])
+		}
+		vertices = append(vertices, [2]float64{lat, lon})
+	}
+	if err := validateRing(vertices, name); err != nil {
+		return nil, err
+	}
+	return vertices, nil
+}
+
+// validateRing checks that a ring has enough vertices and valid coordinates
+func validateRing(vertices [][2]float64, name string) error {
+	if len(vertices) < 3 {
+		return fmt.Errorf("zone %q has a ring with fewer than 3 vertices", name)
+	}
+	for _, v := range vertices {
+		if v[0] < -90 || v[0] > 90 || v[1] < -180 || v[1] > 180 {
+			return fmt.Errorf("zone %q has an invalid coordinate %.7f, %.7f", name, v[0], v[1])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package fp2lm_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const zonesGeoJSON = `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "type": "Feature",
+      "properties": {"name": "Airport"},
+      "geometry": {
+        "type": "Polygon",
+        "coordinates": [
+          [[0, 0], [2, 0], [2, 1], [0, 1], [0, 0]],
+          [[0.5, 0.25], [1, 0.25], [1, 0.5], [0.5, 0.25]]
+        ]
+      }
+    },
+    {
+      "type": "Feature",
+      "properties": {},
+      "geometry": {
+        "type": "MultiPolygon",
+        "coordinates": [[[[10, 10], [11, 10], [11, 11], [10, 10]]]]
+      }
+    },
+    {
+      "type": "Feature",
+      "properties": {"name": "Beacon"},
+      "geometry": {"type": "Point", "coordinates": [5, 5]}
+    }
+  ]
+}`
+
+const zonesKML = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <Folder>
+      <Placemark>
+        <name>Airport</name>
+        <Polygon>
+          <outerBoundaryIs><LinearRing><coordinates>
+            0,0,0 2,0,0 2,1,0 0,1,0 0,0,0
+          </coordinates></LinearRing></outerBoundaryIs>
+          <innerBoundaryIs><LinearRing><coordinates>
+            0.5,0.25 1,0.25 1,0.5 0.5,0.25
+          </coordinates></LinearRing></innerBoundaryIs>
+        </Polygon>
+      </Placemark>
+      <Placemark>
+        <MultiGeometry>
+          <Polygon>
+            <outerBoundaryIs><LinearRing><coordinates>10,10 11,10 11,11 10,10</coordinates></LinearRing></outerBoundaryIs>
+          </Polygon>
+        </MultiGeometry>
+      </Placemark>
+    </Folder>
+  </Document>
+</kml>`
+
+// expectedZones are the zones described by both zonesGeoJSON and zonesKML
+var expectedZones = []fp2lm.Zone{
+	{
+		Name:     "Airport",
+		Kind:     fp2lm.ZoneExclusion,
+		Boundary: [][2]float64{{0, 0}, {0, 2}, {1, 2}, {1, 0}, {0, 0}},
+		Holes:    [][][2]float64{{{0.25, 0.5}, {0.25, 1}, {0.5, 1}, {0.25, 0.5}}},
+	},
+	{
+		Name:     "zone 2",
+		Kind:     fp2lm.ZoneExclusion,
+		Boundary: [][2]float64{{10, 10}, {10, 11}, {11, 11}, {10, 10}},
+	},
+}
+
+// TestReadZones checks that GeoJSON and KML zones are read in latitude, longitude order
+func TestReadZones(t *testing.T) {
+	zones, err := fp2lm.ReadGeoJSONZones(strings.NewReader(zonesGeoJSON), fp2lm.ZoneExclusion)
+	if err != nil {
+		t.Fatalf("ReadGeoJSONZones returned error: %v", err)
+	}
+	if !reflect.DeepEqual(zones, expectedZones) {
+		t.Errorf("GeoJSON: expected %v, got %v", expectedZones, zones)
+	}
+
+	zones, err = fp2lm.ReadKMLZones(strings.NewReader(zonesKML), fp2lm.ZoneExclusion)
+	if err != nil {
+		t.Fatalf("ReadKMLZones returned error: %v", err)
+	}
+	if !reflect.DeepEqual(zones, expectedZones) {
+		t.Errorf("KML: expected %v, got %v", expectedZones, zones)
+	}
+}
+
+// TestReadZonesInvalid checks that malformed files are rejected
+func TestReadZonesInvalid(t *testing.T) {
+	geoJSON := []string{
+		`{"type": "Polygon", "coordinates": [[[0, 0], [1, 1]]]}`,
+		`{"type": "Polygon", "coordinates": [[[0, 0], [1, 100], [1, 0]]]}`,
+		`{"type": "Polygon", "coordinates": "none"}`,
+		`not json`,
+	}
+	for _, input := range geoJSON {
+		if _, err := fp2lm.ReadGeoJSONZones(strings.NewReader(input), fp2lm.ZoneExclusion); err == nil {
+			t.Errorf("expected an error for %s", input)
+		}
+	}
+
+	kml := `<kml><Placemark><Polygon><outerBoundaryIs><LinearRing><coordinates>0,0 x,1 1,1</coordinates></LinearRing></outerBoundaryIs></Polygon></Placemark></kml>`
+	if _, err := fp2lm.ReadKMLZones(strings.NewReader(kml), fp2lm.ZoneExclusion); err == nil {
+		t.Error("expected an error for a malformed KML coordinate")
+	}
+}
+
+// TestLoadZones checks that the file format is chosen by extension
+func TestLoadZones(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"zones.geojson": zonesGeoJSON, "zones.kml": zonesKML, "zones.txt": zonesGeoJSON}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"zones.geojson", "zones.kml"} {
+		zones, err := fp2lm.LoadZones(filepath.Join(dir, name), fp2lm.ZoneInclusion)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(zones) != 2 || zones[0].Kind != fp2lm.ZoneInclusion {
+			t.Errorf("%s: expected 2 inclusion zones, got %v", name, zones)
+		}
+	}
+
+	if _, err := fp2lm.LoadZones(filepath.Join(dir, "zones.txt"), fp2lm.ZoneExclusion); err == nil {
+		t.Error("expected an error for an unknown extension")
+	}
+	if _, err := fp2lm.LoadZones(filepath.Join(dir, "missing.kml"), fp2lm.ZoneExclusion); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
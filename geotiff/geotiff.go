@@ -0,0 +1,469 @@
+// Package geotiff reads elevation rasters, such as digital surface and terrain
+// models, from GeoTIFF files using only the standard library.
+//
+// It supports single-band rasters in WGS84 geographic coordinates, stored in strips or
+// tiles, uncompressed or with Deflate compression and an optional horizontal
+// differencing predictor.
+package geotiff
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TIFF tags used to read the raster
+const (
+	tagImageWidth          = 256
+	tagImageLength         = 257
+	tagBitsPerSample       = 258
+	tagCompression         = 259
+	tagStripOffsets        = 273
+	tagSamplesPerPixel     = 277
+	tagRowsPerStrip        = 278
+	tagStripByteCounts     = 279
+	tagPredictor           = 317
+	tagTileWidth           = 322
+	tagTileLength          = 323
+	tagTileOffsets         = 324
+	tagTileByteCounts      = 325
+	tagSampleFormat        = 339
+	tagModelPixelScale     = 33550
+	tagModelTiepoint       = 33922
+	tagGeoKeyDirectory     = 34735
+	tagGDALNoData          = 42113
+	geoKeyModelType        = 1024
+	geoKeyRasterType       = 1025
+	geoKeyGeographicType   = 2048
+	modelTypeGeographic    = 2
+	rasterPixelIsPoint     = 2
+	geographicTypeWGS84    = 4326
+	compressionNone        = 1
+	compressionDeflate     = 8
+	compressionDeflateOld  = 32946
+	predictorNone          = 1
+	predictorHorizontal    = 2
+	sampleFormatUnsigned   = 1
+	sampleFormatSigned     = 2
+	sampleFormatIEEEFloat  = 3
+	typeShort, typeLong    = 3, 4
+	typeASCII, typeDouble  = 2, 12
+	classicTIFFMagicNumber = 42
+)
+
+// typeSizes gives the size in bytes of each TIFF field type
+var typeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// Raster is a grid of elevations in meters covering a geographic area
+type Raster struct {
+	width, height int
+	values        []float32
+
+	// Coordinates of the centre of the top-left pixel and pixel size, in degrees
+	originLat, originLon float64
+	scaleLat, scaleLon   float64
+
+	noData    float64
+	hasNoData bool
+}
+
+// Open reads a raster from a GeoTIFF file
+func Open(path string) (*Raster, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoTIFF: %w", err)
+	}
+	defer file.Close()
+
+	raster, err := Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return raster, nil
+}
+
+// Decode reads a raster from GeoTIFF data
+func Decode(r io.Reader) (*Raster, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoTIFF: %w", err)
+	}
+	t, err := parseTIFF(data)
+	if err != nil {
+		return nil, err
+	}
+	return t.raster()
+}
+
+// Size returns the width and height of the raster in pixels
+func (r *Raster) Size() (width, height int) {
+	return r.width, r.height
+}
+
+// Bounds returns the edges of the area covered by the raster in degrees
+func (r *Raster) Bounds() (south, west, north, east float64) {
+	north = r.originLat + r.scaleLat/2
+	west = r.originLon - r.scaleLon/2
+	south = north - float64(r.height)*r.scaleLat
+	east = west + float64(r.width)*r.scaleLon
+	return south, west, north, east
+}
+
+// Elevation returns the elevation in meters at a coordinate, interpolated bilinearly
+// between the centres of the four nearest pixels
+//
+// An error is returned for coordinates that are not finite, outside the raster or
+// next to pixels with no data.
+func (r *Raster) Elevation(latitude, longitude float64) (float64, error) {
+	// NaN fails every bounds comparison below, so it is rejected first
+	if math.IsNaN(latitude) || math.IsNaN(longitude) || math.IsInf(latitude, 0) || math.IsInf(longitude, 0) {
+		return 0, fmt.Errorf("coordinate %.7f, %.7f is not a finite number", latitude, longitude)
+	}
+	column := (longitude - r.originLon) / r.scaleLon
+	row := (r.originLat - latitude) / r.scaleLat
+	const edge = 0.5 + 1e-9 // half a pixel, allowing for rounding on the outer edge
+	if column < -edge || column > float64(r.width-1)+edge || row < -edge || row > float64(r.height-1)+edge {
+		return 0, fmt.Errorf("coordinate %.7f, %.7f is outside the elevation raster", latitude, longitude)
+	}
+
+	// Pixels on the edge of the raster hold their value out to the edge
+	column = math.Max(0, math.Min(float64(r.width-1), column))
+	row = math.Max(0, math.Min(float64(r.height-1), row))
+	c0, r0 := int(column), int(row)
+	c1, r1 := minInt(c0+1, r.width-1), minInt(r0+1, r.height-1)
+	fc, fr := column-float64(c0), row-float64(r0)
+
+	var elevation float64
+	for _, p := range []struct {
+		c, r   int
+		weight float64
+	}{
+		{c0, r0, (1 - fc) * (1 - fr)},
+		{c1, r0, fc * (1 - fr)},
+		{c0, r1, (1 - fc) * fr},
+		{c1, r1, fc * fr},
+	} {
+		if p.weight == 0 {
+			continue
+		}
+		value := float64(r.values[p.r*r.width+p.c])
+		if math.IsNaN(value) || (r.hasNoData && value == r.noData) {
+			return 0, fmt.Errorf("no elevation data at %.7f, %.7f", latitude, longitude)
+		}
+		elevation += value * p.weight
+	}
+	return elevation, nil
+}
+
+// tiff holds the parsed first image directory of a TIFF file
+type tiff struct {
+	data    []byte
+	order   binary.ByteOrder
+	entries map[uint16]ifdEntry
+}
+
+// ifdEntry is a single field of an image file directory
+type ifdEntry struct {
+	fieldType uint16
+	count     uint32
+	value     []byte
+}
+
+// parseTIFF reads the header and first image file directory
+func parseTIFF(data []byte) (*tiff, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("not a TIFF file")
+	}
+	t := &tiff{data: data, entries: map[uint16]ifdEntry{}}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("not a TIFF file")
+	}
+	if t.order.Uint16(data[2:4]) != classicTIFFMagicNumber {
+		return nil, fmt.Errorf("only classic TIFF files are supported, not BigTIFF")
+	}
+
+	offset := int(t.order.Uint32(data[4:8]))
+	if offset+2 > len(data) {
+		return nil, fmt.Errorf("image directory is outside the file")
+	}
+	count := int(t.order.Uint16(data[offset : offset+2]))
+	if offset+2+count*12 > len(data) {
+		return nil, fmt.Errorf("image directory is truncated")
+	}
+	for i := 0; i < count; i++ {
+		entry := data[offset+2+i*12 : offset+2+(i+1)*12]
+		tag := t.order.Uint16(entry[0:2])
+		fieldType := t.order.Uint16(entry[2:4])
+		n := t.order.Uint32(entry[4:8])
+		size, ok := typeSizes[fieldType]
+		if !ok {
+			continue
+		}
+		length := size * int(n)
+		value := entry[8:12]
+		if length > 4 {
+			start := int(t.order.Uint32(entry[8:12]))
+			if start+length > len(data) {
+				return nil, fmt.Errorf("tag %d is outside the file", tag)
+			}
+			value = data[start : start+length]
+		}
+		t.entries[tag] = ifdEntry{fieldType: fieldType, count: n, value: value[:length]}
+	}
+	return t, nil
+}
+
+// uints returns the values of a SHORT or LONG field
+func (t *tiff) uints(tag uint16) ([]uint64, bool) {
+	entry, ok := t.entries[tag]
+	if !ok {
+		return nil, false
+	}
+	values := make([]uint64, entry.count)
+	for i := range values {
+		switch entry.fieldType {
+		case typeShort:
+			values[i] = uint64(t.order.Uint16(entry.value[i*2:]))
+		case typeLong:
+			values[i] = uint64(t.order.Uint32(entry.value[i*4:]))
+		default:
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// uint returns the first value of a SHORT or LONG field, or fallback when it is absent
+func (t *tiff) uint(tag uint16, fallback uint64) uint64 {
+	if values, ok := t.uints(tag); ok && len(values) > 0 {
+		return values[0]
+	}
+	return fallback
+}
+
+// doubles returns the values of a DOUBLE field
+func (t *tiff) doubles(tag uint16) ([]float64, bool) {
+	entry, ok := t.entries[tag]
+	if !ok || entry.fieldType != typeDouble {
+		return nil, false
+	}
+	values := make([]float64, entry.count)
+	for i := range values {
+		values[i] = math.Float64frombits(t.order.Uint64(entry.value[i*8:]))
+	}
+	return values, true
+}
+
+// raster decodes the image and its georeferencing
+func (t *tiff) raster() (*Raster, error) {
+	width := int(t.uint(tagImageWidth, 0))
+	height := int(t.uint(tagImageLength, 0))
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("image has no size")
+	}
+	if samples := t.uint(tagSamplesPerPixel, 1); samples != 1 {
+		return nil, fmt.Errorf("only single-band rasters are supported, got %d bands", samples)
+	}
+
+	r := &Raster{width: width, height: height}
+	if err := t.georeference(r); err != nil {
+		return nil, err
+	}
+	if entry, ok := t.entries[tagGDALNoData]; ok && entry.fieldType == typeASCII {
+		text := strings.TrimSpace(strings.TrimRight(string(entry.value), "\x00"))
+		if value, err := strconv.ParseFloat(text, 64); err == nil {
+			r.noData, r.hasNoData = value, true
+		}
+	}
+
+	values, err := t.pixels(width, height)
+	if err != nil {
+		return nil, err
+	}
+	r.values = values
+	return r, nil
+}
+
+// georeference reads the pixel scale, tie point and geo keys into the raster
+func (t *tiff) georeference(r *Raster) error {
+	pixelIsPoint := false
+	if keys, ok := t.uints(tagGeoKeyDirectory); ok && len(keys) >= 4 {
+		for i := 0; i < int(keys[3]) && 4+i*4+3 < len(keys); i++ {
+			key, location, value := keys[4+i*4], keys[4+i*4+1], keys[4+i*4+3]
+			if location != 0 {
+				continue
+			}
+			switch key {
+			case geoKeyModelType:
+				if value != modelTypeGeographic {
+					return fmt.Errorf("only geographic (latitude and longitude) rasters are supported")
+				}
+			case geoKeyGeographicType:
+				if value != geographicTypeWGS84 {
+					return fmt.Errorf("only WGS84 (EPSG:4326) rasters are supported, got EPSG:%d", value)
+				}
+			case geoKeyRasterType:
+				pixelIsPoint = value == rasterPixelIsPoint
+			}
+		}
+	}
+
+	scale, ok := t.doubles(tagModelPixelScale)
+	if !ok || len(scale) < 2 || scale[0] <= 0 || scale[1] <= 0 {
+		return fmt.Errorf("raster has no pixel scale")
+	}
+	tiepoint, ok := t.doubles(tagModelTiepoint)
+	if !ok || len(tiepoint) < 6 {
+		return fmt.Errorf("raster has no tie point")
+	}
+
+	r.scaleLon, r.scaleLat = scale[0], scale[1]
+	r.originLon = tiepoint[3] + (0.5-tiepoint[0])*r.scaleLon
+	r.originLat = tiepoint[4] - (0.5-tiepoint[1])*r.scaleLat
+	if pixelIsPoint {
+		// Tie points refer to pixel centres rather than corners
+		r.originLat += r.scaleLat / 2
+		r.originLon -= r.scaleLon / 2
+	}
+	return nil
+}
+
+// pixels decodes every strip or tile into a row-major grid of values
+func (t *tiff) pixels(width, height int) ([]float32, error) {
+	bits := int(t.uint(tagBitsPerSample, 1))
+	format := t.uint(tagSampleFormat, sampleFormatUnsigned)
+	convert, err := sampleConverter(format, bits, t.order)
+	if err != nil {
+		return nil, err
+	}
+	sampleSize := bits / 8
+
+	compression := t.uint(tagCompression, compressionNone)
+	if compression != compressionNone && compression != compressionDeflate && compression != compressionDeflateOld {
+		return nil, fmt.Errorf("unsupported compression %d, expected none or Deflate", compression)
+	}
+	predictor := t.uint(tagPredictor, predictorNone)
+	if predictor != predictorNone && (predictor != predictorHorizontal || format == sampleFormatIEEEFloat) {
+		return nil, fmt.Errorf("unsupported predictor %d", predictor)
+	}
+
+	// Strips are tiles as wide as the image
+	blockWidth, blockHeight := width, int(t.uint(tagRowsPerStrip, uint64(height)))
+	offsets, okOffsets := t.uints(tagStripOffsets)
+	counts, okCounts := t.uints(tagStripByteCounts)
+	_, tiled := t.entries[tagTileWidth]
+	if tiled {
+		blockWidth, blockHeight = int(t.uint(tagTileWidth, 0)), int(t.uint(tagTileLength, 0))
+		offsets, okOffsets = t.uints(tagTileOffsets)
+		counts, okCounts = t.uints(tagTileByteCounts)
+	}
+	if !okOffsets || !okCounts || len(offsets) != len(counts) || blockWidth <= 0 || blockHeight <= 0 {
+		return nil, fmt.Errorf("raster has no valid strip or tile layout")
+	}
+	across := (width + blockWidth - 1) / blockWidth
+	down := (height + blockHeight - 1) / blockHeight
+	if len(offsets) < across*down {
+		return nil, fmt.Errorf("raster has %d blocks, expected %d", len(offsets), across*down)
+	}
+
+	values := make([]float32, width*height)
+	for block := 0; block < across*down; block++ {
+		start, length := int(offsets[block]), int(counts[block])
+		if start+length > len(t.data) {
+			return nil, fmt.Errorf("block %d is outside the file", block)
+		}
+		raw := t.data[start : start+length]
+		if compression != compressionNone {
+			zr, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				return nil, fmt.Errorf("block %d: %w", block, err)
+			}
+			raw, err = io.ReadAll(zr)
+			if err != nil {
+				return nil, fmt.Errorf("block %d: %w", block, err)
+			}
+		}
+
+		x0, y0 := (block%across)*blockWidth, (block/across)*blockHeight
+		// Tiles are always stored at full size; only the last strip may be short
+		rows := blockHeight
+		if !tiled {
+			rows = minInt(blockHeight, height-y0)
+		}
+		if len(raw) < rows*blockWidth*sampleSize {
+			return nil, fmt.Errorf("block %d holds %d bytes, expected %d", block, len(raw), rows*blockWidth*sampleSize)
+		}
+		if predictor == predictorHorizontal {
+			undoHorizontalPredictor(raw, blockWidth, rows, sampleSize, t.order)
+		}
+
+		for y := 0; y < rows && y0+y < height; y++ {
+			for x := 0; x < blockWidth && x0+x < width; x++ {
+				offset := (y*blockWidth + x) * sampleSize
+				values[(y0+y)*width+x0+x] = float32(convert(raw[offset : offset+sampleSize]))
+			}
+		}
+	}
+	return values, nil
+}
+
+// sampleConverter returns a function decoding one sample of the given format and size
+func sampleConverter(format uint64, bits int, order binary.ByteOrder) (func([]byte) float64, error) {
+	switch {
+	case format == sampleFormatUnsigned && bits == 8:
+		return func(b []byte) float64 { return float64(b[0]) }, nil
+	case format == sampleFormatSigned && bits == 8:
+		return func(b []byte) float64 { return float64(int8(b[0])) }, nil
+	case format == sampleFormatUnsigned && bits == 16:
+		return func(b []byte) float64 { return float64(order.Uint16(b)) }, nil
+	case format == sampleFormatSigned && bits == 16:
+		return func(b []byte) float64 { return float64(int16(order.Uint16(b))) }, nil
+	case format == sampleFormatUnsigned && bits == 32:
+		return func(b []byte) float64 { return float64(order.Uint32(b)) }, nil
+	case format == sampleFormatSigned && bits == 32:
+		return func(b []byte) float64 { return float64(int32(order.Uint32(b))) }, nil
+	case format == sampleFormatIEEEFloat && bits == 32:
+		return func(b []byte) float64 { return float64(math.Float32frombits(order.Uint32(b))) }, nil
+	case format == sampleFormatIEEEFloat && bits == 64:
+		return func(b []byte) float64 { return math.Float64frombits(order.Uint64(b)) }, nil
+	}
+	return nil, fmt.Errorf("unsupported sample format %d with %d bits", format, bits)
+}
+
+// undoHorizontalPredictor restores integer samples stored as differences from the
+// previous sample in the same row
+func undoHorizontalPredictor(raw []byte, width, rows, sampleSize int, order binary.ByteOrder) {
+	for y := 0; y < rows; y++ {
+		row := raw[y*width*sampleSize : (y+1)*width*sampleSize]
+		for x := 1; x < width; x++ {
+			prev, cur := row[(x-1)*sampleSize:], row[x*sampleSize:]
+			switch sampleSize {
+			case 1:
+				cur[0] += prev[0]
+			case 2:
+				order.PutUint16(cur, order.Uint16(cur)+order.Uint16(prev))
+			case 4:
+				order.PutUint32(cur, order.Uint32(cur)+order.Uint32(prev))
+			}
+		}
+	}
+}
+
+// minInt returns the smaller of two integers
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
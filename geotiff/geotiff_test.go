@@ -0,0 +1,342 @@
+package geotiff_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"flightplan2litchimission/geotiff"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// rasterSpec describes a GeoTIFF built by encode
+type rasterSpec struct {
+	order         binary.ByteOrder
+	width, height int
+	format, bits  int
+	values        []float64
+
+	// Strips of rowsPerStrip rows, or square tiles when tileSize is set
+	rowsPerStrip, tileSize int
+	deflate, predictor     bool
+
+	// Geo keys, omitted when 0
+	modelType, rasterType, geographicType int
+	noData                                string
+
+	// Pixel size and the coordinate of the tie point at pixel (0, 0), in degrees
+	scaleLon, scaleLat float64
+	tieLon, tieLat     float64
+}
+
+// field is a TIFF field holding []uint16, []uint32, []float64 or a string
+type field struct {
+	tag   uint16
+	value interface{}
+}
+
+// encode builds a GeoTIFF file from a spec
+func encode(t *testing.T, spec rasterSpec) []byte {
+	t.Helper()
+	o := spec.order
+	a := spec.order.(binary.AppendByteOrder)
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 8))
+
+	blockWidth, blockHeight := spec.width, spec.rowsPerStrip
+	if spec.tileSize > 0 {
+		blockWidth, blockHeight = spec.tileSize, spec.tileSize
+	}
+	across := (spec.width + blockWidth - 1) / blockWidth
+	down := (spec.height + blockHeight - 1) / blockHeight
+	size := spec.bits / 8
+
+	var offsets, counts []uint32
+	for by := 0; by < down; by++ {
+		for bx := 0; bx < across; bx++ {
+			rows := blockHeight
+			if spec.tileSize == 0 && (by+1)*blockHeight > spec.height {
+				rows = spec.height - by*blockHeight
+			}
+			block := make([]byte, rows*blockWidth*size)
+			for y := 0; y < rows; y++ {
+				prev := uint64(0)
+				for x := 0; x < blockWidth; x++ {
+					col, row := bx*blockWidth+x, by*blockHeight+y
+					var value float64
+					if col < spec.width && row < spec.height {
+						value = spec.values[row*spec.width+col]
+					}
+					sample := encodeSample(spec, value)
+					if spec.predictor {
+						sample, prev = sample-prev, sample
+					}
+					putSample(o, block[(y*blockWidth+x)*size:], size, sample)
+				}
+			}
+			if spec.deflate {
+				var compressed bytes.Buffer
+				zw := zlib.NewWriter(&compressed)
+				zw.Write(block)
+				zw.Close()
+				block = compressed.Bytes()
+			}
+			offsets = append(offsets, uint32(buf.Len()))
+			counts = append(counts, uint32(len(block)))
+			buf.Write(block)
+		}
+	}
+
+	compression, predictor := uint16(1), uint16(1)
+	if spec.deflate {
+		compression = 8
+	}
+	if spec.predictor {
+		predictor = 2
+	}
+	fields := []field{
+		{256, []uint32{uint32(spec.width)}},
+		{257, []uint32{uint32(spec.height)}},
+		{258, []uint16{uint16(spec.bits)}},
+		{259, []uint16{compression}},
+		{277, []uint16{1}},
+		{317, []uint16{predictor}},
+		{339, []uint16{uint16(spec.format)}},
+		{33550, []float64{spec.scaleLon, spec.scaleLat, 0}},
+		{33922, []float64{0, 0, 0, spec.tieLon, spec.tieLat, 0}},
+	}
+	if spec.tileSize > 0 {
+		fields = append(fields,
+			field{322, []uint16{uint16(spec.tileSize)}}, field{323, []uint16{uint16(spec.tileSize)}},
+			field{324, offsets}, field{325, counts})
+	} else {
+		fields = append(fields, field{273, offsets}, field{278, []uint16{uint16(spec.rowsPerStrip)}}, field{279, counts})
+	}
+	keys := []uint16{1, 1, 0, 0}
+	for _, key := range [][2]int{{1024, spec.modelType}, {1025, spec.rasterType}, {2048, spec.geographicType}} {
+		if key[1] != 0 {
+			keys = append(keys, uint16(key[0]), 0, 1, uint16(key[1]))
+			keys[3]++
+		}
+	}
+	fields = append(fields, field{34735, keys})
+	if spec.noData != "" {
+		fields = append(fields, field{42113, spec.noData + "\x00"})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].tag < fields[j].tag })
+
+	// Write values that do not fit in an entry before the directory
+	entries := make([][]byte, len(fields))
+	for i, f := range fields {
+		var fieldType uint16
+		var data []byte
+		var count int
+		switch v := f.value.(type) {
+		case []uint16:
+			fieldType, count = 3, len(v)
+			for _, n := range v {
+				data = a.AppendUint16(data, n)
+			}
+		case []uint32:
+			fieldType, count = 4, len(v)
+			for _, n := range v {
+				data = a.AppendUint32(data, n)
+			}
+		case []float64:
+			fieldType, count = 12, len(v)
+			for _, n := range v {
+				data = a.AppendUint64(data, math.Float64bits(n))
+			}
+		case string:
+			fieldType, count, data = 2, len(v), []byte(v)
+		}
+		entry := a.AppendUint16(nil, f.tag)
+		entry = a.AppendUint16(entry, fieldType)
+		entry = a.AppendUint32(entry, uint32(count))
+		if len(data) > 4 {
+			entry = a.AppendUint32(entry, uint32(buf.Len()))
+			buf.Write(data)
+		} else {
+			entry = append(entry, append(data, make([]byte, 4-len(data))...)...)
+		}
+		entries[i] = entry
+	}
+
+	ifd := buf.Len()
+	buf.Write(a.AppendUint16(nil, uint16(len(entries))))
+	for _, entry := range entries {
+		buf.Write(entry)
+	}
+	buf.Write(make([]byte, 4))
+
+	data := buf.Bytes()
+	if o == binary.ByteOrder(binary.LittleEndian) {
+		copy(data, "II")
+	} else {
+		copy(data, "MM")
+	}
+	o.PutUint16(data[2:], 42)
+	o.PutUint32(data[4:], uint32(ifd))
+	return data
+}
+
+// encodeSample returns the bits of a sample in the spec's format
+func encodeSample(spec rasterSpec, value float64) uint64 {
+	switch {
+	case spec.format == 3 && spec.bits == 32:
+		return uint64(math.Float32bits(float32(value)))
+	case spec.format == 3:
+		return math.Float64bits(value)
+	default:
+		return uint64(int64(value))
+	}
+}
+
+// putSample writes the low size bytes of a sample
+func putSample(o binary.ByteOrder, b []byte, size int, sample uint64) {
+	switch size {
+	case 1:
+		b[0] = byte(sample)
+	case 2:
+		o.PutUint16(b, uint16(sample))
+	case 4:
+		o.PutUint32(b, uint32(sample))
+	case 8:
+		o.PutUint64(b, sample)
+	}
+}
+
+// decode reads a raster from a spec, failing the test on error
+func decode(t *testing.T, spec rasterSpec) *geotiff.Raster {
+	t.Helper()
+	raster, err := geotiff.Decode(bytes.NewReader(encode(t, spec)))
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+	return raster
+}
+
+// TestDecodeStrips checks an uncompressed float raster stored in strips
+func TestDecodeStrips(t *testing.T) {
+	raster := decode(t, rasterSpec{
+		order: binary.LittleEndian, width: 3, height: 3, format: 3, bits: 32,
+		values:       []float64{10, 11, 12, 20, 21, 22, 30, 31, 32.5},
+		rowsPerStrip: 2,
+		modelType:    2, rasterType: 1, geographicType: 4326,
+		scaleLon: 0.001, scaleLat: 0.001, tieLon: -89, tieLat: 43,
+	})
+
+	south, west, north, east := raster.Bounds()
+	if math.Abs(south-42.997) > 1e-9 || west != -89 || north != 43 || math.Abs(east+88.997) > 1e-9 {
+		t.Errorf("unexpected bounds %f, %f, %f, %f", south, west, north, east)
+	}
+
+	tests := []struct {
+		name      string
+		lat, lon  float64
+		elevation float64
+	}{
+		{"Top-left pixel centre", 42.9995, -88.9995, 10},
+		{"Bottom-right pixel centre in the short strip", 42.9975, -88.9975, 32.5},
+		{"Between four pixels", 42.999, -88.999, 15.5},
+		{"Outer edge holds the edge pixel", 43, -89, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			elevation, err := raster.Elevation(tt.lat, tt.lon)
+			if err != nil || math.Abs(elevation-tt.elevation) > 1e-6 {
+				t.Errorf("expected %.2f, got %.2f (%v)", tt.elevation, elevation, err)
+			}
+		})
+	}
+
+	if _, err := raster.Elevation(43.001, -88.999); err == nil {
+		t.Error("expected an error outside the raster")
+	}
+	if _, err := raster.Elevation(math.NaN(), math.NaN()); err == nil {
+		t.Error("expected an error for a NaN coordinate")
+	}
+	if _, err := raster.Elevation(42.999, math.Inf(-1)); err == nil {
+		t.Error("expected an error for an infinite coordinate")
+	}
+}
+
+// TestDecodeTiles checks a Deflate-compressed integer raster stored in tiles with a
+// predictor and pixel-is-point georeferencing
+func TestDecodeTiles(t *testing.T) {
+	width, height := 20, 18
+	values := make([]float64, width*height)
+	for i := range values {
+		values[i] = float64(i%width*3 - i/width*7)
+	}
+	raster := decode(t, rasterSpec{
+		order: binary.BigEndian, width: width, height: height, format: 2, bits: 16,
+		values:   values,
+		tileSize: 16, deflate: true, predictor: true,
+		modelType: 2, rasterType: 2,
+		scaleLon: 0.0001, scaleLat: 0.0001, tieLon: 10, tieLat: 50,
+	})
+
+	if w, h := raster.Size(); w != width || h != height {
+		t.Errorf("expected %dx%d, got %dx%d", width, height, w, h)
+	}
+	for _, pixel := range [][2]int{{0, 0}, {15, 15}, {16, 3}, {19, 17}, {5, 16}} {
+		col, row := pixel[0], pixel[1]
+		elevation, err := raster.Elevation(50-float64(row)*0.0001, 10+float64(col)*0.0001)
+		expected := values[row*width+col]
+		if err != nil || math.Abs(elevation-expected) > 1e-6 {
+			t.Errorf("pixel %d, %d: expected %.1f, got %.1f (%v)", col, row, expected, elevation, err)
+		}
+	}
+}
+
+// TestDecodeNoData checks that pixels with no data are not interpolated
+func TestDecodeNoData(t *testing.T) {
+	raster := decode(t, rasterSpec{
+		order: binary.LittleEndian, width: 2, height: 2, format: 2, bits: 16,
+		values:       []float64{5, 6, -9999, 8},
+		rowsPerStrip: 2,
+		noData:       "-9999",
+		scaleLon:     1, scaleLat: 1, tieLon: 0, tieLat: 2,
+	})
+	if elevation, err := raster.Elevation(1.5, 0.5); err != nil || elevation != 5 {
+		t.Errorf("expected 5 at a pixel centre, got %.1f (%v)", elevation, err)
+	}
+	if _, err := raster.Elevation(1, 1); err == nil || !strings.Contains(err.Error(), "no elevation data") {
+		t.Errorf("expected a no data error, got %v", err)
+	}
+}
+
+// TestDecodeUnsupported checks that rasters that are not WGS84 geographic are rejected
+func TestDecodeUnsupported(t *testing.T) {
+	spec := rasterSpec{
+		order: binary.LittleEndian, width: 1, height: 1, format: 1, bits: 8,
+		values: []float64{1}, rowsPerStrip: 1, scaleLon: 1, scaleLat: 1,
+	}
+	tests := []struct {
+		name     string
+		modify   func(*rasterSpec)
+		expected string
+	}{
+		{"Projected", func(s *rasterSpec) { s.modelType = 1 }, "only geographic"},
+		{"Other datum", func(s *rasterSpec) { s.modelType, s.geographicType = 2, 4269 }, "EPSG:4269"},
+		{"No pixel scale", func(s *rasterSpec) { s.scaleLon = 0 }, "no pixel scale"},
+		{"Unsupported samples", func(s *rasterSpec) { s.bits = 4 }, "unsupported sample format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := spec
+			tt.modify(&s)
+			_, err := geotiff.Decode(bytes.NewReader(encode(t, s)))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	if _, err := geotiff.Decode(strings.NewReader("not a tiff")); err == nil {
+		t.Error("expected an error for data that is not a TIFF")
+	}
+}
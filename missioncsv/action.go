@@ -0,0 +1,93 @@
+package missioncsv
+
+import (
+	"fmt"
+)
+
+// MaxActions is the number of action slots in each Litchi waypoint
+const MaxActions = 15
+
+// ActionType identifies a Litchi waypoint action
+type ActionType int8
+
+// Litchi waypoint action types
+const (
+	// ActionStay hovers at the waypoint. Param is the duration in milliseconds.
+	ActionStay ActionType = 0
+	// ActionTakePhoto takes a single photo. Param is unused.
+	ActionTakePhoto ActionType = 1
+	// ActionStartRecording starts video recording. Param is unused.
+	ActionStartRecording ActionType = 2
+	// ActionStopRecording stops video recording. Param is unused.
+	ActionStopRecording ActionType = 3
+	// ActionRotateAircraft turns the aircraft. Param is the heading in degrees.
+	ActionRotateAircraft ActionType = 4
+	// ActionTiltCamera tilts the gimbal. Param is the pitch in degrees.
+	ActionTiltCamera ActionType = 5
+	// ActionZoom sets the camera zoom. Param is the zoom ratio.
+	ActionZoom ActionType = 6
+	// ActionFocus triggers autofocus. Param is unused.
+	ActionFocus ActionType = 7
+)
+
+// actionRange holds the name and allowed parameter range of an action type
+type actionRange struct {
+	name     string
+	min, max int16
+}
+
+// actionRanges lists every action type Litchi understands with its parameter range
+var actionRanges = map[ActionType]actionRange{
+	ActionStay:           {"stay", 0, 32000},
+	ActionTakePhoto:      {"take photo", 0, 0},
+	ActionStartRecording: {"start recording", 0, 0},
+	ActionStopRecording:  {"stop recording", 0, 0},
+	ActionRotateAircraft: {"rotate aircraft", -180, 360},
+	ActionTiltCamera:     {"tilt camera", -90, 30},
+	ActionZoom:           {"zoom", 1, 30},
+	ActionFocus:          {"focus", 0, 0},
+}
+
+// String returns the human-readable name of the action type
+func (t ActionType) String() string {
+	if r, ok := actionRanges[t]; ok {
+		return r.name
+	}
+	return fmt.Sprintf("action type %d", int8(t))
+}
+
+// Action represents an action to perform at a waypoint
+type Action struct {
+	Type ActionType
+	// Param is wide enough for stay durations in milliseconds and rotations in degrees
+	Param int16
+}
+
+// Validate checks that the action type is known to Litchi and its parameter is in range
+func (a Action) Validate() error {
+	r, ok := actionRanges[a.Type]
+	if !ok {
+		return fmt.Errorf("unknown action type %d", int8(a.Type))
+	}
+	if a.Param < r.min || a.Param > r.max {
+		if r.min == r.max {
+			return fmt.Errorf("%s action takes no parameter, got %d", r.name, a.Param)
+		}
+		return fmt.Errorf("%s parameter must be between %d and %d, got %d", r.name, r.min, r.max, a.Param)
+	}
+	return nil
+}
+
+// ValidateActions checks that a waypoint's actions fit in the Litchi action slots
+// and that each one is valid
+func ValidateActions(actions []Action) error {
+	if len(actions) > MaxActions {
+		return fmt.Errorf("%d actions exceed the Litchi limit of %d", len(actions), MaxActions)
+	}
+	for i, a := range actions {
+		if err := a.Validate(); err != nil {
+			return fmt.Errorf("action %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package missioncsv_test
+
+import (
+	"bytes"
+	"flightplan2litchimission/missioncsv"
+	"testing"
+)
+
+// TestActionValidate checks parameter ranges for each action type
+func TestActionValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      missioncsv.Action
+		expectError bool
+	}{
+		{"Stay 2s", missioncsv.Action{Type: missioncsv.ActionStay, Param: 2000}, false},
+		{"Stay too long", missioncsv.Action{Type: missioncsv.ActionStay, Param: 32001}, true},
+		{"Photo", missioncsv.Action{Type: missioncsv.ActionTakePhoto}, false},
+		{"Photo with param", missioncsv.Action{Type: missioncsv.ActionTakePhoto, Param: 5}, true},
+		{"Rotate", missioncsv.Action{Type: missioncsv.ActionRotateAircraft, Param: 270}, false},
+		{"Tilt up too far", missioncsv.Action{Type: missioncsv.ActionTiltCamera, Param: 45}, true},
+		{"Zoom", missioncsv.Action{Type: missioncsv.ActionZoom, Param: 2}, false},
+		{"Zoom out of range", missioncsv.Action{Type: missioncsv.ActionZoom, Param: 0}, true},
+		{"Unknown type", missioncsv.Action{Type: 42}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.action.Validate()
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			} else if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestWriteLitchiWaypointTooManyActions checks that excess actions are an error, not truncated
+func TestWriteLitchiWaypointTooManyActions(t *testing.T) {
+	wp := missioncsv.NewLitchiWaypoint()
+	for len(wp.Actions) <= missioncsv.MaxActions {
+		wp.Actions = append(wp.Actions, missioncsv.Action{Type: missioncsv.ActionTakePhoto})
+	}
+
+	var out bytes.Buffer
+	writer := missioncsv.NewWriter(&out)
+	if err := writer.WriteLitchiWaypoint(wp); err == nil {
+		t.Error("expected an error for 16 actions")
+	}
+	writer.Flush()
+	if out.Len() != 0 {
+		t.Errorf("expected nothing to be written, got %q", out.String())
+	}
+}
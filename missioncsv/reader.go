@@ -0,0 +1,168 @@
+package missioncsv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Reader reads waypoints from a Litchi mission CSV file
+type Reader struct {
+	csvReader *csv.Reader
+}
+
+// NewReader creates a new mission CSV reader that reads from the provided reader
+func NewReader(r io.Reader) *Reader {
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	return &Reader{csvReader: csvReader}
+}
+
+// ReadLitchiMission reads the header and every waypoint of a Litchi mission
+//
+// Columns are matched by their header names, so files exported by Litchi Mission Hub
+// and files written by Writer can both be read. The latitude, longitude and altitude
+// columns are required; any other missing column keeps the value given by
+// NewLitchiWaypoint. Actions with type -1, which Litchi uses for empty slots, are
+// skipped. An error names the line and column of the first value that cannot be parsed.
+func (r *Reader) ReadLitchiMission() ([]*LitchiWaypoint, error) {
+	header, err := r.csvReader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("mission is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"latitude", "longitude", "altitude(m)"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("mission header has no %q column", required)
+		}
+	}
+
+	var waypoints []*LitchiWaypoint
+	for line := 2; ; line++ {
+		record, err := r.csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		wp, err := parseLitchiRecord(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		waypoints = append(waypoints, wp)
+	}
+	return waypoints, nil
+}
+
+// recordParser reads typed values from a record by column name, keeping the first error
+type recordParser struct {
+	record  []string
+	columns map[string]int
+	err     error
+}
+
+// text returns the trimmed value of a column and whether the column is present
+func (p *recordParser) text(name string) (string, bool) {
+	i, ok := p.columns[name]
+	if !ok || i >= len(p.record) {
+		return "", false
+	}
+	return strings.TrimSpace(p.record[i]), true
+}
+
+// float parses a decimal column into dst, leaving dst unchanged if the column is absent
+func (p *recordParser) float(name string, dst *float64) {
+	text, ok := p.text(name)
+	if !ok || p.err != nil {
+		return
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		p.err = fmt.Errorf("invalid %s %q", name, text)
+		return
+	}
+	*dst = value
+}
+
+// float32 parses a decimal column into dst, leaving dst unchanged if the column is absent
+func (p *recordParser) float32(name string, dst *float32) {
+	value := float64(*dst)
+	p.float(name, &value)
+	*dst = float32(value)
+}
+
+// integer parses a whole number column within bitSize bits, returning ok false if
+// the column is absent
+func (p *recordParser) integer(name string, bitSize int) (value int64, ok bool) {
+	text, ok := p.text(name)
+	if !ok || p.err != nil {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(text, 10, bitSize)
+	if err != nil {
+		p.err = fmt.Errorf("invalid %s %q", name, text)
+		return 0, false
+	}
+	return value, true
+}
+
+// int8 parses a small whole number column into dst, leaving dst unchanged if the
+// column is absent
+func (p *recordParser) int8(name string, dst *int8) {
+	if value, ok := p.integer(name, 8); ok {
+		*dst = int8(value)
+	}
+}
+
+// parseLitchiRecord converts one CSV record into a waypoint
+func parseLitchiRecord(record []string, columns map[string]int) (*LitchiWaypoint, error) {
+	p := &recordParser{record: record, columns: columns}
+	wp := NewLitchiWaypoint()
+	wp.Actions = nil
+
+	p.float("latitude", &wp.Point.Latitude)
+	p.float("longitude", &wp.Point.Longitude)
+	p.float("altitude(m)", &wp.Point.Altitude)
+	p.float32("heading(deg)", &wp.Heading)
+	p.float32("curvesize(m)", &wp.CurveSize)
+	p.int8("rotationdir", &wp.RotationDir)
+	p.int8("gimbalmode", &wp.GimbalMode)
+	p.float32("gimbalpitchangle", &wp.GimbalPitch)
+	p.int8("altitudemode", &wp.AltitudeMode)
+	p.float32("speed(m/s)", &wp.Speed)
+	p.float("poi_latitude", &wp.POI.Latitude)
+	p.float("poi_longitude", &wp.POI.Longitude)
+	p.float("poi_altitude(m)", &wp.POI.Altitude)
+	p.int8("poi_altitudemode", &wp.POIAltMode)
+	p.float32("photo_timeinterval", &wp.PhotoTimeInterval)
+	p.float32("photo_distinterval", &wp.PhotoDistInterval)
+
+	for i := 1; i <= MaxActions; i++ {
+		actionType, ok := p.integer(fmt.Sprintf("actiontype%d", i), 8)
+		if !ok || actionType == -1 {
+			continue
+		}
+		param, _ := p.integer(fmt.Sprintf("actionparam%d", i), 16)
+		wp.Actions = append(wp.Actions, Action{Type: ActionType(actionType), Param: int16(param)})
+	}
+
+	if p.err != nil {
+		return nil, p.err
+	}
+	return wp, nil
+}
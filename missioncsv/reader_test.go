@@ -0,0 +1,102 @@
+package missioncsv_test
+
+import (
+	"bytes"
+	"flightplan2litchimission/missioncsv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestReadLitchiMissionRoundTrip checks that waypoints written by Writer read back unchanged
+func TestReadLitchiMissionRoundTrip(t *testing.T) {
+	wp := missioncsv.NewLitchiWaypoint()
+	wp.Point = missioncsv.Point{Latitude: 43.0009225, Longitude: -89.000307, Altitude: 30.5}
+	wp.Heading = 90.5
+	wp.CurveSize = 12.5
+	wp.GimbalMode = 2
+	wp.GimbalPitch = -45
+	wp.Speed = 7.5
+	wp.POI = missioncsv.POI{Latitude: 43.001, Longitude: -89.001, Altitude: 10}
+	wp.POIAltMode = 1
+	wp.PhotoDistInterval = 20
+	wp.Actions = []missioncsv.Action{
+		{Type: missioncsv.ActionStay, Param: 2000},
+		{Type: missioncsv.ActionRotateAircraft, Param: 270},
+	}
+
+	var buf bytes.Buffer
+	writer := missioncsv.NewWriter(&buf)
+	if err := writer.WriteLitchiHeader(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteLitchiWaypoint(wp); err != nil {
+		t.Fatal(err)
+	}
+	writer.Flush()
+
+	waypoints, err := missioncsv.NewReader(&buf).ReadLitchiMission()
+	if err != nil {
+		t.Fatalf("ReadLitchiMission returned error: %v", err)
+	}
+	if len(waypoints) != 1 {
+		t.Fatalf("expected 1 waypoint, got %d", len(waypoints))
+	}
+
+	// Writer pads the unused action slots with zeros, which read back as zero-length stays
+	got := waypoints[0]
+	if !reflect.DeepEqual(got.Actions[:2], wp.Actions) || len(got.Actions) != missioncsv.MaxActions {
+		t.Errorf("expected actions %v followed by padding, got %v", wp.Actions, got.Actions)
+	}
+	got.Actions = wp.Actions
+	if !reflect.DeepEqual(got, wp) {
+		t.Errorf("expected %+v, got %+v", wp, got)
+	}
+}
+
+// TestReadLitchiMissionExport checks a Litchi export with empty action slots and
+// a reduced set of columns
+func TestReadLitchiMissionExport(t *testing.T) {
+	input := "latitude,longitude,altitude(m),heading(deg),actiontype1,actionparam1,actiontype2,actionparam2\n" +
+		"43.1,-89.2,50,180,1,0,-1,0\n" +
+		"\n" +
+		"43.2,-89.3,60,270,-1,0,-1,0\n"
+
+	waypoints, err := missioncsv.NewReader(strings.NewReader(input)).ReadLitchiMission()
+	if err != nil {
+		t.Fatalf("ReadLitchiMission returned error: %v", err)
+	}
+	if len(waypoints) != 2 {
+		t.Fatalf("expected 2 waypoints, got %d", len(waypoints))
+	}
+	if waypoints[0].Heading != 180 || len(waypoints[0].Actions) != 1 || waypoints[0].Actions[0].Type != missioncsv.ActionTakePhoto {
+		t.Errorf("unexpected first waypoint %+v", waypoints[0])
+	}
+	if waypoints[1].Point.Altitude != 60 || len(waypoints[1].Actions) != 0 {
+		t.Errorf("unexpected second waypoint %+v", waypoints[1])
+	}
+	if waypoints[1].AltitudeMode != 1 || waypoints[1].GimbalPitch != -90 {
+		t.Errorf("expected missing columns to keep their defaults, got %+v", waypoints[1])
+	}
+}
+
+// TestReadLitchiMissionInvalid checks that malformed missions are rejected
+func TestReadLitchiMissionInvalid(t *testing.T) {
+	tests := []struct {
+		name, input, message string
+	}{
+		{"Empty", "", "empty"},
+		{"Missing column", "latitude,longitude\n43,-89\n", `"altitude(m)"`},
+		{"Bad latitude", "latitude,longitude,altitude(m)\n43,-89,30\nnorth,-89,30\n", `line 3: invalid latitude "north"`},
+		{"Bad action", "latitude,longitude,altitude(m),actiontype1,actionparam1\n43,-89,30,1,99999\n", "actionparam1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := missioncsv.NewReader(strings.NewReader(tt.input)).ReadLitchiMission()
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected an error mentioning %s, got %v", tt.message, err)
+			}
+		})
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
-	"log/slog"
 )
 
+// MaxLitchiWaypoints is the largest number of waypoints Litchi accepts in a single mission
+const MaxLitchiWaypoints = 99
+
 // Point represents a waypoint with its geographic coordinates
 type Point struct {
 	Latitude  float64
@@ -47,12 +49,6 @@ type LitchiWaypoint struct {
 	Actions []Action
 }
 
-// Action represents an action to perform at a waypoint
-type Action struct {
-	Type  int8
-	Param int8
-}
-
 // Writer handles writing waypoints to a Litchi-compatible CSV file
 type Writer struct {
 	csvWriter *csv.Writer
@@ -80,18 +76,17 @@ func (w *Writer) WriteLitchiHeader() error {
 }
 
 // WriteLitchiWaypoint writes a single waypoint in Litchi format
+//
+// The waypoint's actions are validated first, and an error is returned without
+// writing anything if there are more than MaxActions or any parameter is out of range.
 func (w *Writer) WriteLitchiWaypoint(wp *LitchiWaypoint) error {
-	// Ensure we have at least 15 actions (padding with zeros if needed)
-	actions := wp.Actions
-	for len(actions) < 15 {
-		actions = append(actions, Action{Type: 0, Param: 0})
+	if err := ValidateActions(wp.Actions); err != nil {
+		return fmt.Errorf("waypoint at %.7f, %.7f: %w", wp.Point.Latitude, wp.Point.Longitude, err)
 	}
 
-	// Truncate if we somehow have more than 15 actions
-	if len(actions) > 15 {
-		actions = actions[:15]
-		slog.Warn("Truncated excess actions for waypoint", "latitude", wp.Point.Latitude, "longitude", wp.Point.Longitude)
-	}
+	// Ensure we have exactly 15 actions (padding with zeros if needed)
+	actions := make([]Action, MaxActions)
+	copy(actions, wp.Actions)
 
 	row := []string{
 		fmt.Sprintf("%.7f", wp.Point.Latitude),
@@ -105,7 +100,7 @@ func (w *Writer) WriteLitchiWaypoint(wp *LitchiWaypoint) error {
 	}
 
 	// Add all 15 actions
-	for i := 0; i < 15; i++ {
+	for i := 0; i < MaxActions; i++ {
 		row = append(row,
 			fmt.Sprintf("%d", actions[i].Type),
 			fmt.Sprintf("%d", actions[i].Param),
@@ -138,7 +133,7 @@ func (w *Writer) Error() error {
 }
 
 // CreateDefaultAction creates a default action with specified type and param
-func CreateDefaultAction(actionType, param int8) Action {
+func CreateDefaultAction(actionType ActionType, param int16) Action {
 	return Action{Type: actionType, Param: param}
 }
 
@@ -166,7 +161,7 @@ func NewLitchiWaypoint() *LitchiWaypoint {
 		PhotoTimeInterval: -1,
 		PhotoDistInterval: -1,
 		Actions: []Action{
-			{Type: 1, Param: 0}, // Default take photo action
+			{Type: ActionTakePhoto, Param: 0}, // Default take photo action
 		},
 	}
 }
@@ -0,0 +1,98 @@
+package missionview
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+)
+
+// Size in pixels of the charts in the HTML preview
+const (
+	previewPlanWidth     = 900
+	previewPlanHeight    = 600
+	previewProfileHeight = 280
+)
+
+// previewTemplate lays out the preview with everything inline, so the file opens
+// without a network connection
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; color: #222222; }
+svg { max-width: 100%; height: auto; }
+table { border-collapse: collapse; font-size: 0.85em; }
+th, td { border: 1px solid #cccccc; padding: 0.2em 0.5em; text-align: right; }
+td:last-child { text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Count}} waypoints, {{printf "%.0f" .Length}} m route, altitude {{printf "%.1f" .MinAltitude}}–{{printf "%.1f" .MaxAltitude}} m {{.Reference}}</p>
+<h2>Plan view</h2>
+{{.Plan}}
+<h2>Altitude profile</h2>
+{{.Profile}}
+<h2>Waypoints</h2>
+<table>
+<tr><th>#</th><th>Latitude</th><th>Longitude</th><th>Altitude (m)</th><th>Heading (°)</th><th>Pitch (°)</th><th>Speed (m/s)</th><th>Curve (m)</th><th>Actions</th></tr>
+{{range .Rows}}<tr><td>{{.Number}}</td><td>{{printf "%.7f" .Point.Latitude}}</td><td>{{printf "%.7f" .Point.Longitude}}</td><td>{{printf "%.1f" .Point.Altitude}}</td><td>{{printf "%.0f" .Heading}}</td><td>{{printf "%.0f" .GimbalPitch}}</td><td>{{if gt .Speed 0.0}}{{printf "%.1f" .Speed}}{{else}}cruise{{end}}</td><td>{{printf "%.1f" .CurveSize}}</td><td>{{.Actions}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// previewRow is a waypoint with its number and actions prepared for the table
+type previewRow struct {
+	*missioncsv.LitchiWaypoint
+	Number  int
+	Actions string
+}
+
+// WriteHTML writes a self-contained HTML preview of a mission: a plan view and an
+// altitude profile drawn as inline SVG, each waypoint labeled with its heading, gimbal
+// pitch and actions, and a table of every waypoint
+//
+// The page uses no scripts, fonts or images from the network, so it can be opened in
+// the field without a connection.
+func WriteHTML(w io.Writer, title string, waypoints []*missioncsv.LitchiWaypoint) error {
+	profile, err := BuildProfile(waypoints, DefaultProfileOptions())
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		Title                    string
+		Count                    int
+		Length                   float64
+		MinAltitude, MaxAltitude float64
+		Reference                string
+		Plan, Profile            template.HTML
+		Rows                     []previewRow
+	}{
+		Title:       title,
+		Count:       len(waypoints),
+		Length:      fp2lm.MeasureRoute(waypoints).Total,
+		MinAltitude: math.Inf(1),
+		MaxAltitude: math.Inf(-1),
+		Reference:   altitudeReference(waypoints),
+		Plan:        template.HTML(planSVG(waypoints, &PlanOptions{Width: previewPlanWidth, Height: previewPlanHeight, Labels: true})),
+		Profile:     template.HTML(profileSVG(waypoints, profile, 0, previewPlanWidth, previewProfileHeight)),
+	}
+	for i, wp := range waypoints {
+		data.MinAltitude = math.Min(data.MinAltitude, wp.Point.Altitude)
+		data.MaxAltitude = math.Max(data.MaxAltitude, wp.Point.Altitude)
+		data.Rows = append(data.Rows, previewRow{LitchiWaypoint: wp, Number: i + 1, Actions: actionsLabel(wp)})
+	}
+
+	if err := previewTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to write HTML preview: %w", err)
+	}
+	return nil
+}
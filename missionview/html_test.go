@@ -0,0 +1,63 @@
+package missionview_test
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"flightplan2litchimission/missionview"
+	"strings"
+	"testing"
+)
+
+// mission returns three waypoints flying east and then north, with a stay and a
+// rotation at the corner
+func mission() []*missioncsv.LitchiWaypoint {
+	var waypoints []*missioncsv.LitchiWaypoint
+	for i, c := range [][2]float64{{43, -89}, {43, -88.999}, {43.001, -88.999}} {
+		wp := missioncsv.NewLitchiWaypoint()
+		wp.Point = missioncsv.Point{Latitude: c[0], Longitude: c[1], Altitude: 30 + float64(i)*10}
+		wp.Heading = 90
+		waypoints = append(waypoints, wp)
+	}
+	waypoints[1].Actions = append(waypoints[1].Actions,
+		missioncsv.Action{Type: missioncsv.ActionStay, Param: 2000},
+		missioncsv.Action{Type: missioncsv.ActionRotateAircraft, Param: 0})
+	waypoints[2].Heading, waypoints[2].GimbalPitch = 0, -45
+	return waypoints
+}
+
+// TestWriteHTML checks that the preview holds both charts, the waypoint labels and no
+// external resources
+func TestWriteHTML(t *testing.T) {
+	var b strings.Builder
+	if err := missionview.WriteHTML(&b, "Survey <north>", mission()); err != nil {
+		t.Fatalf("WriteHTML returned error: %v", err)
+	}
+	page := b.String()
+
+	for _, expected := range []string{
+		"<title>Survey &lt;north&gt;</title>",
+		"3 waypoints, 193 m route, altitude 30.0–50.0 m above takeoff",
+		"2: heading 90°, pitch -90°, take photo, stay 2s, rotate aircraft 0°",
+		"3: heading 0°, pitch -45°, take photo",
+		"Altitude (m, above takeoff)",
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("expected the preview to contain %q", expected)
+		}
+	}
+	if n := strings.Count(page, "<svg"); n != 2 {
+		t.Errorf("expected a plan view and an altitude profile, got %d charts", n)
+	}
+	for _, external := range []string{"<script", "src=", "href=", "@import"} {
+		if strings.Contains(page, external) {
+			t.Errorf("expected no external resources, found %q", external)
+		}
+	}
+}
+
+// TestWriteHTMLEmpty checks that an empty mission is rejected
+func TestWriteHTMLEmpty(t *testing.T) {
+	var b strings.Builder
+	if err := missionview.WriteHTML(&b, "Empty", nil); err == nil {
+		t.Error("expected an error for a mission with no waypoints")
+	}
+}
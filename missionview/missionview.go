@@ -0,0 +1,84 @@
+// Package missionview renders converted Litchi missions for review in the field, such
+// as a self-contained HTML preview with a plan view and an altitude profile.
+package missionview
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// validateWaypoints checks that there is something to draw
+func validateWaypoints(waypoints []*missioncsv.LitchiWaypoint) error {
+	if len(waypoints) == 0 {
+		return fmt.Errorf("mission has no waypoints")
+	}
+	return nil
+}
+
+// actionLabel describes an action with its parameter where it has one
+func actionLabel(action missioncsv.Action) string {
+	switch action.Type {
+	case missioncsv.ActionStay:
+		return fmt.Sprintf("stay %gs", float64(action.Param)/1000)
+	case missioncsv.ActionRotateAircraft:
+		return fmt.Sprintf("rotate aircraft %d°", action.Param)
+	case missioncsv.ActionTiltCamera:
+		return fmt.Sprintf("tilt camera %d°", action.Param)
+	case missioncsv.ActionZoom:
+		return fmt.Sprintf("zoom %dx", action.Param)
+	}
+	return action.Type.String()
+}
+
+// actionsLabel lists a waypoint's actions, or "none"
+func actionsLabel(wp *missioncsv.LitchiWaypoint) string {
+	if len(wp.Actions) == 0 {
+		return "none"
+	}
+	labels := make([]string, len(wp.Actions))
+	for i, action := range wp.Actions {
+		labels[i] = actionLabel(action)
+	}
+	return strings.Join(labels, ", ")
+}
+
+// waypointLabel gives the number, heading, gimbal pitch and actions of a waypoint
+func waypointLabel(index int, wp *missioncsv.LitchiWaypoint) string {
+	return fmt.Sprintf("%d: heading %.0f°, pitch %.0f°, %s", index+1, wp.Heading, wp.GimbalPitch, actionsLabel(wp))
+}
+
+// altitudeReference describes what the waypoints' altitudes are measured from
+func altitudeReference(waypoints []*missioncsv.LitchiWaypoint) string {
+	relative, absolute := false, false
+	for _, wp := range waypoints {
+		if wp.AltitudeMode == 1 {
+			relative = true
+		} else {
+			absolute = true
+		}
+	}
+	switch {
+	case relative && !absolute:
+		return "above takeoff"
+	case absolute && !relative:
+		return "above sea level"
+	}
+	return "mixed reference"
+}
+
+// niceStep returns a round step of 1, 2 or 5 times a power of ten close to span/count
+func niceStep(span float64, count int) float64 {
+	if span <= 0 {
+		return 1
+	}
+	raw := span / float64(count)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5} {
+		if raw <= m*magnitude {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
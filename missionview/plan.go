@@ -0,0 +1,217 @@
+package missionview
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+)
+
+// Sizes in pixels of the features of the plan view
+const (
+	planMargin      = 40
+	markerRadius    = 5
+	photoRadius     = 2.5
+	headingTickSize = 16
+	arrowSize       = 7
+)
+
+// Colours of the features of the plan view
+var (
+	backgroundColor = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	borderColor     = color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	legColor        = color.RGBA{0x1f, 0x77, 0xb4, 0xff}
+	startColor      = color.RGBA{0x2c, 0xa0, 0x2c, 0xff}
+	endColor        = color.RGBA{0xd6, 0x27, 0x28, 0xff}
+	headingColor    = color.RGBA{0x94, 0x67, 0xbd, 0xff}
+	photoColor      = color.RGBA{0xff, 0x7f, 0x0e, 0xff}
+	textColor       = color.RGBA{0x33, 0x33, 0x33, 0xff}
+)
+
+// PlanOptions configures a plan view image
+type PlanOptions struct {
+	// Width and Height are the size of the image in pixels
+	Width  int
+	Height int
+
+	// Labels adds each waypoint's heading, gimbal pitch and actions next to its number.
+	// Labels are drawn in SVG only; PNG images show the numbers alone.
+	Labels bool
+}
+
+// DefaultPlanOptions returns options for a 1200 by 900 pixel image without labels
+func DefaultPlanOptions() *PlanOptions {
+	return &PlanOptions{Width: 1200, Height: 900, Labels: false}
+}
+
+// validate checks that the image is large enough to hold the route and its margins
+func (o *PlanOptions) validate() error {
+	if o.Width < 4*planMargin || o.Height < 4*planMargin {
+		return fmt.Errorf("plan view must be at least %d by %d pixels, got %d by %d",
+			4*planMargin, 4*planMargin, o.Width, o.Height)
+	}
+	return nil
+}
+
+// planDrawing holds the features of a plan view in image coordinates, shared by the
+// SVG and PNG renderers
+type planDrawing struct {
+	width, height float64
+	points        [][2]float64
+	colors        []color.RGBA
+	arrows        [][3][2]float64
+	ticks         [][2][2]float64
+	photos        [][2]float64
+
+	// scaleMeters is the length of the scale bar in meters and scaleLength in pixels
+	scaleMeters, scaleLength float64
+}
+
+// newPlanDrawing fits the waypoints into an image with north up and equal scales on
+// both axes, and lays out every feature
+func newPlanDrawing(waypoints []*missioncsv.LitchiWaypoint, width, height float64) planDrawing {
+	frame := fp2lm.NewLocalPlane(waypoints[0].Point.Latitude, waypoints[0].Point.Longitude)
+	xy := make([][2]float64, len(waypoints))
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for i, wp := range waypoints {
+		x, y := frame.ToXY(wp.Point.Latitude, wp.Point.Longitude)
+		xy[i] = [2]float64{x, y}
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+
+	// A single waypoint or a very short route is shown at a scale covering 10 m
+	spanX, spanY := math.Max(maxX-minX, 10), math.Max(maxY-minY, 10)
+	scale := math.Min((width-2*planMargin)/spanX, (height-2*planMargin)/spanY)
+	centreX, centreY := (minX+maxX)/2, (minY+maxY)/2
+	project := func(x, y float64) [2]float64 {
+		return [2]float64{width/2 + (x-centreX)*scale, height/2 - (y-centreY)*scale}
+	}
+
+	d := planDrawing{width: width, height: height}
+	for i, p := range xy {
+		point := project(p[0], p[1])
+		d.points = append(d.points, point)
+
+		c := legColor
+		if i == 0 {
+			c = startColor
+		} else if i == len(xy)-1 {
+			c = endColor
+		}
+		d.colors = append(d.colors, c)
+
+		// Heading ticks point the way the aircraft faces, clockwise from north
+		heading := float64(waypoints[i].Heading) * math.Pi / 180
+		d.ticks = append(d.ticks, [2][2]float64{point,
+			{point[0] + math.Sin(heading)*headingTickSize, point[1] - math.Cos(heading)*headingTickSize}})
+	}
+
+	// Direction arrows at the middle of legs long enough to hold one
+	for i := 1; i < len(d.points); i++ {
+		from, to := d.points[i-1], d.points[i]
+		dx, dy := to[0]-from[0], to[1]-from[1]
+		length := math.Hypot(dx, dy)
+		if length < 4*arrowSize {
+			continue
+		}
+		ux, uy := dx/length, dy/length
+		mid := [2]float64{(from[0] + to[0]) / 2, (from[1] + to[1]) / 2}
+		d.arrows = append(d.arrows, [3][2]float64{
+			{mid[0] + ux*arrowSize, mid[1] + uy*arrowSize},
+			{mid[0] - ux*arrowSize - uy*arrowSize*0.6, mid[1] - uy*arrowSize + ux*arrowSize*0.6},
+			{mid[0] - ux*arrowSize + uy*arrowSize*0.6, mid[1] - uy*arrowSize - ux*arrowSize*0.6},
+		})
+	}
+
+	for _, photo := range fp2lm.SimulatePhotos(waypoints) {
+		x, y := frame.ToXY(photo.Latitude, photo.Longitude)
+		d.photos = append(d.photos, project(x, y))
+	}
+
+	d.scaleMeters = niceStep(100/scale, 1)
+	d.scaleLength = d.scaleMeters * scale
+	return d
+}
+
+// WritePlanSVG draws the mission from above as an SVG image: legs with direction
+// arrows, numbered waypoints, heading ticks and the positions where photos are taken
+// from fp2lm.SimulatePhotos, with a north arrow and a scale bar
+func WritePlanSVG(w io.Writer, waypoints []*missioncsv.LitchiWaypoint, options *PlanOptions) error {
+	if err := validateWaypoints(waypoints); err != nil {
+		return err
+	}
+	if err := options.validate(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, planSVG(waypoints, options)); err != nil {
+		return fmt.Errorf("failed to write plan view: %w", err)
+	}
+	return nil
+}
+
+// planSVG renders the plan view as an SVG document
+func planSVG(waypoints []*missioncsv.LitchiWaypoint, options *PlanOptions) string {
+	d := newPlanDrawing(waypoints, float64(options.Width), float64(options.Height))
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %.0f %.0f" width="%.0f" height="%.0f" font-family="sans-serif">`+"\n",
+		d.width, d.height, d.width, d.height)
+	fmt.Fprintf(&b, `<rect width="%.0f" height="%.0f" fill="%s" stroke="%s"/>`+"\n", d.width, d.height, hex(backgroundColor), hex(borderColor))
+
+	// Legs and their direction arrows
+	if len(d.points) > 1 {
+		coords := make([]string, len(d.points))
+		for i, p := range d.points {
+			coords[i] = fmt.Sprintf("%.1f,%.1f", p[0], p[1])
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", strings.Join(coords, " "), hex(legColor))
+	}
+	for _, a := range d.arrows {
+		fmt.Fprintf(&b, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s"/>`+"\n",
+			a[0][0], a[0][1], a[1][0], a[1][1], a[2][0], a[2][1], hex(legColor))
+	}
+
+	// Photo positions, then heading ticks and waypoints on top
+	for _, p := range d.photos {
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="%g" fill="%s"/>`+"\n", p[0], p[1], photoRadius, hex(photoColor))
+	}
+	for _, t := range d.ticks {
+		fmt.Fprintf(&b, `<path d="M %.1f %.1f L %.1f %.1f" stroke="%s" stroke-width="2"/>`+"\n",
+			t[0][0], t[0][1], t[1][0], t[1][1], hex(headingColor))
+	}
+	for i, p := range d.points {
+		label := html.EscapeString(waypointLabel(i, waypoints[i]))
+		text := fmt.Sprint(i + 1)
+		if options.Labels {
+			text = label
+		}
+
+		// Text on the right half of the image runs leftward to stay on the canvas
+		x, anchor := p[0]+markerRadius+2, "start"
+		if p[0] > d.width/2 {
+			x, anchor = p[0]-markerRadius-2, "end"
+		}
+		fmt.Fprintf(&b, `<g><title>%s</title><circle cx="%.1f" cy="%.1f" r="%d" fill="%s"/>`, label, p[0], p[1], markerRadius, hex(d.colors[i]))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" font-size="10" text-anchor="%s" fill="%s">%s</text></g>`+"\n",
+			x, p[1]-4, anchor, hex(textColor), text)
+	}
+
+	// North arrow in the top right corner and scale bar in the bottom left
+	x := d.width - 20
+	fmt.Fprintf(&b, `<path d="M %.1f 12 L %.1f 32 L %.1f 27 L %.1f 32 Z" fill="%s"/>`, x, x+6, x, x-6, hex(textColor))
+	fmt.Fprintf(&b, `<text x="%.1f" y="44" font-size="11" text-anchor="middle" fill="%s">N</text>`+"\n", x, hex(textColor))
+	y := d.height - 15
+	fmt.Fprintf(&b, `<path d="M 10 %.1f h %.1f" stroke="%s" stroke-width="3"/>`, y, d.scaleLength, hex(textColor))
+	fmt.Fprintf(&b, `<text x="10" y="%.1f" font-size="11" fill="%s">%g m</text>`+"\n", y-6, hex(textColor), d.scaleMeters)
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// hex formats a colour for SVG
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
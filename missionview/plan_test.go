@@ -0,0 +1,92 @@
+package missionview_test
+
+import (
+	"bytes"
+	"flightplan2litchimission/missionview"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// TestWritePlanSVG checks the number of arrows, heading ticks and photo positions
+func TestWritePlanSVG(t *testing.T) {
+	waypoints := mission()
+	for _, wp := range waypoints {
+		wp.PhotoDistInterval = 20
+	}
+
+	var b strings.Builder
+	if err := missionview.WritePlanSVG(&b, waypoints, missionview.DefaultPlanOptions()); err != nil {
+		t.Fatalf("WritePlanSVG returned error: %v", err)
+	}
+	svg := b.String()
+
+	// Photos at the three waypoints plus every 20 m along legs of 81.5 and 111.2 m
+	counts := map[string]int{
+		`<polygon `:              2,
+		`stroke="#9467bd"`:       3,
+		`fill="#ff7f0e"`:         12,
+		`<circle cx`:             15,
+		`>2</text>`:              1,
+		"rotate aircraft 0°":     1,
+		"heading 0°, pitch -45°": 1,
+	}
+	for fragment, expected := range counts {
+		if n := strings.Count(svg, fragment); n != expected {
+			t.Errorf("expected %d of %q, got %d", expected, fragment, n)
+		}
+	}
+
+	// Labels replace the numbers
+	b.Reset()
+	options := missionview.DefaultPlanOptions()
+	options.Labels = true
+	if err := missionview.WritePlanSVG(&b, waypoints, options); err != nil {
+		t.Fatalf("WritePlanSVG returned error: %v", err)
+	}
+	if !strings.Contains(b.String(), ">3: heading 0°, pitch -45°, take photo</text>") {
+		t.Error("expected waypoint labels")
+	}
+}
+
+// TestWritePlanPNG checks the image size and that the start marker is drawn
+func TestWritePlanPNG(t *testing.T) {
+	var b bytes.Buffer
+	options := &missionview.PlanOptions{Width: 400, Height: 300}
+	if err := missionview.WritePlanPNG(&b, mission(), options); err != nil {
+		t.Fatalf("WritePlanPNG returned error: %v", err)
+	}
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 400 || size.Y != 300 {
+		t.Errorf("expected 400x300, got %v", size)
+	}
+
+	// The route fills the height, so the first waypoint sits on the bottom margin
+	green := 0
+	for y := 250; y < 270; y++ {
+		for x := 0; x < 400; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			if r>>8 == 0x2c && g>>8 == 0xa0 && bl>>8 == 0x2c {
+				green++
+			}
+		}
+	}
+	if green == 0 {
+		t.Error("expected the start marker near the bottom of the image")
+	}
+}
+
+// TestPlanOptionsInvalid checks that images too small for the margins are rejected
+func TestPlanOptionsInvalid(t *testing.T) {
+	var b bytes.Buffer
+	options := &missionview.PlanOptions{Width: 100, Height: 900}
+	if err := missionview.WritePlanPNG(&b, mission(), options); err == nil {
+		t.Error("expected an error for a narrow image")
+	}
+	if err := missionview.WritePlanSVG(&b, nil, missionview.DefaultPlanOptions()); err == nil {
+		t.Error("expected an error for a mission with no waypoints")
+	}
+}
@@ -0,0 +1,200 @@
+package missionview
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// glyphScale is the size in pixels of each dot of the bitmap font
+const glyphScale = 2
+
+// glyphs is a small bitmap font with the characters needed for waypoint numbers, the
+// north arrow and the scale bar
+var glyphs = map[rune][5]string{
+	'0': {"###", "# #", "# #", "# #", "###"},
+	'1': {" # ", "## ", " # ", " # ", "###"},
+	'2': {"###", "  #", "###", "#  ", "###"},
+	'3': {"###", "  #", "###", "  #", "###"},
+	'4': {"# #", "# #", "###", "  #", "  #"},
+	'5': {"###", "#  ", "###", "  #", "###"},
+	'6': {"###", "#  ", "###", "# #", "###"},
+	'7': {"###", "  #", "  #", "  #", "  #"},
+	'8': {"###", "# #", "###", "# #", "###"},
+	'9': {"###", "# #", "###", "  #", "###"},
+	'.': {" ", " ", " ", " ", "#"},
+	' ': {" ", " ", " ", " ", " "},
+	'm': {"     ", "#### ", "# # #", "# # #", "# # #"},
+	'N': {"#  #", "## #", "# ##", "#  #", "#  #"},
+}
+
+// WritePlanPNG draws the same plan view as WritePlanSVG as a PNG image, without
+// waypoint labels
+func WritePlanPNG(w io.Writer, waypoints []*missioncsv.LitchiWaypoint, options *PlanOptions) error {
+	if err := validateWaypoints(waypoints); err != nil {
+		return err
+	}
+	if err := options.validate(); err != nil {
+		return err
+	}
+	d := newPlanDrawing(waypoints, float64(options.Width), float64(options.Height))
+
+	c := canvas{image.NewRGBA(image.Rect(0, 0, options.Width, options.Height))}
+	c.fillRect(0, 0, options.Width, options.Height, backgroundColor)
+	c.strokeRect(borderColor)
+
+	for i := 1; i < len(d.points); i++ {
+		c.line(d.points[i-1], d.points[i], 2, legColor)
+	}
+	for _, a := range d.arrows {
+		c.fillTriangle(a, legColor)
+	}
+	for _, p := range d.photos {
+		c.fillCircle(p, photoRadius, photoColor)
+	}
+	for _, t := range d.ticks {
+		c.line(t[0], t[1], 2, headingColor)
+	}
+	for i, p := range d.points {
+		c.fillCircle(p, markerRadius, d.colors[i])
+	}
+	for i, p := range d.points {
+		text := fmt.Sprint(i + 1)
+		x := int(p[0]) + markerRadius + 2
+		if p[0] > d.width/2 {
+			x = int(p[0]) - markerRadius - 2 - textWidth(text)
+		}
+		c.text(x, int(p[1])-4-5*glyphScale, text, textColor, true)
+	}
+
+	// North arrow in the top right corner and scale bar in the bottom left
+	x := d.width - 20
+	c.fillTriangle([3][2]float64{{x, 12}, {x + 6, 32}, {x - 6, 32}}, textColor)
+	c.text(int(x)-textWidth("N")/2, 36, "N", textColor, false)
+	y := d.height - 15
+	c.line([2]float64{10, y}, [2]float64{10 + d.scaleLength, y}, 3, textColor)
+	c.text(10, int(y)-6-5*glyphScale, fmt.Sprintf("%g m", d.scaleMeters), textColor, false)
+
+	if err := png.Encode(w, c.img); err != nil {
+		return fmt.Errorf("failed to write plan view: %w", err)
+	}
+	return nil
+}
+
+// canvas draws antialiased shapes onto an RGBA image
+type canvas struct {
+	img *image.RGBA
+}
+
+// blend mixes a colour into the pixel at x, y with the given coverage (0-1)
+func (c canvas) blend(x, y int, col color.RGBA, coverage float64) {
+	if !(image.Point{x, y}.In(c.img.Rect)) || coverage <= 0 {
+		return
+	}
+	coverage = math.Min(coverage, 1)
+	old := c.img.RGBAAt(x, y)
+	mix := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a)*(1-coverage) + float64(b)*coverage))
+	}
+	c.img.SetRGBA(x, y, color.RGBA{mix(old.R, col.R), mix(old.G, col.G), mix(old.B, col.B), 0xff})
+}
+
+// fillRect fills a rectangle without antialiasing
+func (c canvas) fillRect(x, y, width, height int, col color.RGBA) {
+	for py := y; py < y+height; py++ {
+		for px := x; px < x+width; px++ {
+			c.blend(px, py, col, 1)
+		}
+	}
+}
+
+// strokeRect draws a one pixel border around the image
+func (c canvas) strokeRect(col color.RGBA) {
+	b := c.img.Rect
+	c.fillRect(0, 0, b.Dx(), 1, col)
+	c.fillRect(0, b.Dy()-1, b.Dx(), 1, col)
+	c.fillRect(0, 0, 1, b.Dy(), col)
+	c.fillRect(b.Dx()-1, 0, 1, b.Dy(), col)
+}
+
+// fillCircle fills a circle centred on p
+func (c canvas) fillCircle(p [2]float64, radius float64, col color.RGBA) {
+	for y := int(p[1] - radius - 1); y <= int(p[1]+radius+1); y++ {
+		for x := int(p[0] - radius - 1); x <= int(p[0]+radius+1); x++ {
+			distance := math.Hypot(float64(x)+0.5-p[0], float64(y)+0.5-p[1])
+			c.blend(x, y, col, radius+0.5-distance)
+		}
+	}
+}
+
+// line draws a straight line of the given width with rounded ends
+func (c canvas) line(from, to [2]float64, width float64, col color.RGBA) {
+	half := width / 2
+	dx, dy := to[0]-from[0], to[1]-from[1]
+	lengthSq := dx*dx + dy*dy
+	minX, maxX := math.Min(from[0], to[0])-half-1, math.Max(from[0], to[0])+half+1
+	minY, maxY := math.Min(from[1], to[1])-half-1, math.Max(from[1], to[1])+half+1
+	for y := int(minY); y <= int(maxY); y++ {
+		for x := int(minX); x <= int(maxX); x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			t := 0.0
+			if lengthSq > 0 {
+				t = math.Max(0, math.Min(1, ((px-from[0])*dx+(py-from[1])*dy)/lengthSq))
+			}
+			distance := math.Hypot(px-from[0]-t*dx, py-from[1]-t*dy)
+			c.blend(x, y, col, half+0.5-distance)
+		}
+	}
+}
+
+// fillTriangle fills a triangle without antialiasing
+func (c canvas) fillTriangle(t [3][2]float64, col color.RGBA) {
+	minX := math.Min(t[0][0], math.Min(t[1][0], t[2][0]))
+	maxX := math.Max(t[0][0], math.Max(t[1][0], t[2][0]))
+	minY := math.Min(t[0][1], math.Min(t[1][1], t[2][1]))
+	maxY := math.Max(t[0][1], math.Max(t[1][1], t[2][1]))
+	edge := func(a, b [2]float64, x, y float64) float64 {
+		return (b[0]-a[0])*(y-a[1]) - (b[1]-a[1])*(x-a[0])
+	}
+	for y := int(minY); y <= int(maxY); y++ {
+		for x := int(minX); x <= int(maxX); x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			e0, e1, e2 := edge(t[0], t[1], px, py), edge(t[1], t[2], px, py), edge(t[2], t[0], px, py)
+			if (e0 >= 0 && e1 >= 0 && e2 >= 0) || (e0 <= 0 && e1 <= 0 && e2 <= 0) {
+				c.blend(x, y, col, 1)
+			}
+		}
+	}
+}
+
+// text draws a string in the bitmap font with its top left corner at x, y, optionally
+// on a white background so it stays legible over legs
+func (c canvas) text(x, y int, s string, col color.RGBA, background bool) {
+	if background {
+		c.fillRect(x-1, y-1, textWidth(s)+2, 5*glyphScale+2, backgroundColor)
+	}
+	for _, r := range s {
+		glyph := glyphs[r]
+		for row, line := range glyph {
+			for column, dot := range line {
+				if dot == '#' {
+					c.fillRect(x+column*glyphScale, y+row*glyphScale, glyphScale, glyphScale, col)
+				}
+			}
+		}
+		x += (len(glyph[0]) + 1) * glyphScale
+	}
+}
+
+// textWidth returns the width in pixels of a string in the bitmap font
+func textWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += (len(glyphs[r][0]) + 1) * glyphScale
+	}
+	return width - glyphScale
+}
@@ -0,0 +1,341 @@
+package missionview
+
+import (
+	"encoding/csv"
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Space in pixels around the profile chart for the axes and their labels
+const (
+	profileMarginLeft   = 60
+	profileMarginRight  = 20
+	profileMarginTop    = 20
+	profileMarginBottom = 40
+)
+
+// Colours of the features of the profile chart
+var (
+	gridColor    = color.RGBA{0xee, 0xee, 0xee, 0xff}
+	axisColor    = color.RGBA{0x55, 0x55, 0x55, 0xff}
+	terrainColor = color.RGBA{0xa0, 0x82, 0x5a, 0xff}
+	warningColor = color.RGBA{0xd6, 0x27, 0x28, 0xff}
+)
+
+// ProfileOptions configures an altitude profile
+type ProfileOptions struct {
+	// Terrain optionally provides ground elevations. When set, each leg is sampled and
+	// the profile gives the terrain elevation and clearance beneath the aircraft, with
+	// altitudes converted to above sea level.
+	Terrain fp2lm.ElevationModel
+
+	// Home optionally sets the takeoff point, whose terrain elevation relative
+	// altitudes are measured from. Without it the first waypoint is used. Only needed
+	// with Terrain.
+	Home *missioncsv.Point
+
+	// Step is the distance in meters between terrain samples along each leg
+	Step float64
+
+	// MinClearance highlights points less than this many meters above the terrain in
+	// the chart. 0 highlights only points below the terrain.
+	MinClearance float64
+}
+
+// DefaultProfileOptions returns options without terrain, sampling every 10 m when
+// terrain is set
+func DefaultProfileOptions() *ProfileOptions {
+	return &ProfileOptions{Terrain: nil, Home: nil, Step: 10, MinClearance: 0}
+}
+
+// ProfilePoint is a point of an altitude profile
+type ProfilePoint struct {
+	// Distance is the distance in meters along the route
+	Distance float64
+
+	// Waypoint is the index (from 0) of the waypoint at this point, or -1 for points
+	// sampled between waypoints
+	Waypoint int
+
+	Latitude  float64
+	Longitude float64
+
+	// Altitude is the planned altitude as given in the mission, in meters
+	Altitude float64
+
+	// AltitudeASL, Terrain and Clearance are the planned altitude above sea level, the
+	// terrain elevation and the height above the terrain in meters. They are NaN
+	// without a terrain model.
+	AltitudeASL float64
+	Terrain     float64
+	Clearance   float64
+}
+
+// BuildProfile measures the planned altitude against distance along the route
+//
+// Without a terrain model the profile holds one point per waypoint. With one, points
+// are placed every Step meters along each leg by fp2lm.SampleRoute, which interpolates
+// the altitude above sea level between waypoints, and relative altitudes are measured
+// from the terrain at Home or the first waypoint (see fp2lm.TakeoffElevation).
+func BuildProfile(waypoints []*missioncsv.LitchiWaypoint, options *ProfileOptions) ([]ProfilePoint, error) {
+	if err := validateWaypoints(waypoints); err != nil {
+		return nil, err
+	}
+	if options.Terrain != nil && options.Step <= 0 {
+		return nil, fmt.Errorf("profile step must be positive, got %.1f", options.Step)
+	}
+
+	// An infinite step samples only the waypoints
+	step := math.Inf(1)
+	var takeoffElevation float64
+	if options.Terrain != nil {
+		step = options.Step
+		var err error
+		takeoffElevation, err = fp2lm.TakeoffElevation(waypoints, options.Home, options.Terrain, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	samples, err := fp2lm.SampleRoute(waypoints, takeoffElevation, step)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := make([]ProfilePoint, len(samples))
+	for i, sample := range samples {
+		// Points between waypoints give the altitude in the mode of the leg's start
+		altitude := sample.AltitudeASL
+		if waypoints[sample.Leg].AltitudeMode == 1 {
+			altitude -= takeoffElevation
+		}
+		if sample.Waypoint >= 0 {
+			altitude = waypoints[sample.Waypoint].Point.Altitude
+		}
+		p := ProfilePoint{
+			Distance:    sample.Distance,
+			Waypoint:    sample.Waypoint,
+			Latitude:    sample.Latitude,
+			Longitude:   sample.Longitude,
+			Altitude:    altitude,
+			AltitudeASL: math.NaN(),
+			Terrain:     math.NaN(),
+			Clearance:   math.NaN(),
+		}
+		if options.Terrain != nil {
+			terrain, err := options.Terrain.Elevation(sample.Latitude, sample.Longitude)
+			if err != nil {
+				return nil, fmt.Errorf("%.0f m along the route: %w", sample.Distance, err)
+			}
+			p.AltitudeASL = sample.AltitudeASL
+			p.Terrain = terrain
+			p.Clearance = sample.AltitudeASL - terrain
+		}
+		profile[i] = p
+	}
+	return profile, nil
+}
+
+// WriteProfileCSV writes an altitude profile as CSV with a header row
+//
+// The terrain columns are empty when the profile has no terrain. The waypoint column
+// numbers waypoints from 1 and is empty for points sampled between them.
+func WriteProfileCSV(w io.Writer, waypoints []*missioncsv.LitchiWaypoint, options *ProfileOptions) error {
+	profile, err := BuildProfile(waypoints, options)
+	if err != nil {
+		return err
+	}
+
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write([]string{"distance(m)", "waypoint", "latitude", "longitude", "altitude(m)",
+		"altitude_asl(m)", "terrain(m)", "clearance(m)"})
+	for _, p := range profile {
+		waypoint := ""
+		if p.Waypoint >= 0 {
+			waypoint = strconv.Itoa(p.Waypoint + 1)
+		}
+		csvWriter.Write([]string{
+			fmt.Sprintf("%.1f", p.Distance),
+			waypoint,
+			fmt.Sprintf("%.7f", p.Latitude),
+			fmt.Sprintf("%.7f", p.Longitude),
+			fmt.Sprintf("%.1f", p.Altitude),
+			optionalMeters(p.AltitudeASL),
+			optionalMeters(p.Terrain),
+			optionalMeters(p.Clearance),
+		})
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	return nil
+}
+
+// optionalMeters formats a length, or an empty string for NaN
+func optionalMeters(value float64) string {
+	if math.IsNaN(value) {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", value)
+}
+
+// WriteProfileSVG charts an altitude profile as an SVG image 900 by 320 pixels, with
+// distance along the route on the X axis and each waypoint numbered
+//
+// With a terrain model the terrain is filled in beneath the planned altitude, and
+// points less than MinClearance above it are marked in red.
+func WriteProfileSVG(w io.Writer, waypoints []*missioncsv.LitchiWaypoint, options *ProfileOptions) error {
+	profile, err := BuildProfile(waypoints, options)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, profileSVG(waypoints, profile, options.MinClearance, 900, 320)); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	return nil
+}
+
+// profileChart maps distance along the route and altitude onto an SVG canvas
+type profileChart struct {
+	width, height      float64
+	maxDistance        float64
+	minAlt, maxAlt     float64
+	plotWidth, plotTop float64
+	plotHeight         float64
+}
+
+// newProfileChart fits the given distances and altitudes into a canvas
+func newProfileChart(distances, altitudes []float64, width, height float64) profileChart {
+	c := profileChart{
+		width:      width,
+		height:     height,
+		plotWidth:  width - profileMarginLeft - profileMarginRight,
+		plotTop:    profileMarginTop,
+		plotHeight: height - profileMarginTop - profileMarginBottom,
+		minAlt:     math.Inf(1),
+		maxAlt:     math.Inf(-1),
+	}
+	for _, d := range distances {
+		c.maxDistance = math.Max(c.maxDistance, d)
+	}
+	for _, a := range altitudes {
+		c.minAlt, c.maxAlt = math.Min(c.minAlt, a), math.Max(c.maxAlt, a)
+	}
+
+	// Pad the altitude range so level flight is not drawn along an edge
+	pad := math.Max((c.maxAlt-c.minAlt)*0.1, 5)
+	c.minAlt, c.maxAlt = c.minAlt-pad, c.maxAlt+pad
+	c.maxDistance = math.Max(c.maxDistance, 1)
+	return c
+}
+
+// x returns the horizontal position of a distance along the route
+func (c profileChart) x(distance float64) float64 {
+	return profileMarginLeft + distance/c.maxDistance*c.plotWidth
+}
+
+// y returns the vertical position of an altitude
+func (c profileChart) y(altitude float64) float64 {
+	return c.plotTop + (c.maxAlt-altitude)/(c.maxAlt-c.minAlt)*c.plotHeight
+}
+
+// writeAxes draws grid lines and labels for both axes
+func (c profileChart) writeAxes(b *strings.Builder, altitudeLabel string) {
+	fmt.Fprintf(b, `<rect width="%.0f" height="%.0f" fill="%s" stroke="%s"/>`+"\n", c.width, c.height, hex(backgroundColor), hex(borderColor))
+
+	step := niceStep(c.maxDistance, 8)
+	for d := 0.0; d <= c.maxDistance+1e-9; d += step {
+		x := c.x(d)
+		fmt.Fprintf(b, `<path d="M %.1f %.1f V %.1f" stroke="%s"/>`, x, c.plotTop, c.plotTop+c.plotHeight, hex(gridColor))
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" font-size="10" text-anchor="middle" fill="%s">%g</text>`+"\n",
+			x, c.plotTop+c.plotHeight+14, hex(axisColor), d)
+	}
+	step = niceStep(c.maxAlt-c.minAlt, 5)
+	for a := math.Ceil(c.minAlt/step) * step; a <= c.maxAlt; a += step {
+		y := c.y(a)
+		fmt.Fprintf(b, `<path d="M %d %.1f H %.1f" stroke="%s"/>`, profileMarginLeft, y, profileMarginLeft+c.plotWidth, hex(gridColor))
+		fmt.Fprintf(b, `<text x="%d" y="%.1f" font-size="10" text-anchor="end" fill="%s">%g</text>`+"\n",
+			profileMarginLeft-6, y+3, hex(axisColor), a)
+	}
+
+	fmt.Fprintf(b, `<text x="%.1f" y="%.1f" font-size="11" text-anchor="middle" fill="%s">Distance along route (m)</text>`+"\n",
+		profileMarginLeft+c.plotWidth/2, c.height-8, hex(textColor))
+	fmt.Fprintf(b, `<text x="14" y="%.1f" font-size="11" text-anchor="middle" fill="%s" transform="rotate(-90 14 %.1f)">%s</text>`+"\n",
+		c.plotTop+c.plotHeight/2, hex(textColor), c.plotTop+c.plotHeight/2, html.EscapeString(altitudeLabel))
+}
+
+// coords formats the chart positions of the given distances and altitudes
+func (c profileChart) coords(distances, altitudes []float64) string {
+	coords := make([]string, len(distances))
+	for i := range distances {
+		coords[i] = fmt.Sprintf("%.1f,%.1f", c.x(distances[i]), c.y(altitudes[i]))
+	}
+	return strings.Join(coords, " ")
+}
+
+// profileSVG charts a profile of the waypoints, drawing the terrain when the profile
+// has it and marking points less than minClearance above it
+func profileSVG(waypoints []*missioncsv.LitchiWaypoint, profile []ProfilePoint, minClearance, width, height float64) string {
+	hasTerrain := len(profile) > 0 && !math.IsNaN(profile[0].Terrain)
+	distances := make([]float64, len(profile))
+	altitudes := make([]float64, len(profile))
+	terrain := make([]float64, len(profile))
+	for i, p := range profile {
+		distances[i], altitudes[i], terrain[i] = p.Distance, p.Altitude, p.Terrain
+		if hasTerrain {
+			altitudes[i] = p.AltitudeASL
+		}
+	}
+	reference := altitudeReference(waypoints)
+	extent := altitudes
+	if hasTerrain {
+		reference = "above sea level"
+		extent = append(append([]float64{}, altitudes...), terrain...)
+	}
+	chart := newProfileChart(distances, extent, width, height)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %.0f %.0f" width="%.0f" height="%.0f" font-family="sans-serif">`+"\n",
+		width, height, width, height)
+	chart.writeAxes(&b, "Altitude (m, "+reference+")")
+
+	// Terrain filled down to the bottom of the chart
+	if hasTerrain {
+		bottom := chart.plotTop + chart.plotHeight
+		fmt.Fprintf(&b, `<polygon points="%.1f,%.1f %s %.1f,%.1f" fill="%s" fill-opacity="0.5" stroke="%s"/>`+"\n",
+			chart.x(distances[0]), bottom, chart.coords(distances, terrain), chart.x(distances[len(distances)-1]), bottom,
+			hex(terrainColor), hex(terrainColor))
+	}
+
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", chart.coords(distances, altitudes), hex(legColor))
+
+	// Points too close to the terrain
+	if hasTerrain {
+		for i, p := range profile {
+			if p.Clearance < minClearance {
+				fmt.Fprintf(&b, `<g><title>%.0f m along the route: %.1f m above the terrain</title><circle cx="%.1f" cy="%.1f" r="3" fill="%s"/></g>`+"\n",
+					p.Distance, p.Clearance, chart.x(distances[i]), chart.y(altitudes[i]), hex(warningColor))
+			}
+		}
+	}
+
+	for i, p := range profile {
+		if p.Waypoint < 0 {
+			continue
+		}
+		x, y := chart.x(distances[i]), chart.y(altitudes[i])
+		fmt.Fprintf(&b, `<g><title>%s, altitude %.1f m</title><circle cx="%.1f" cy="%.1f" r="4" fill="%s"/>`,
+			html.EscapeString(waypointLabel(p.Waypoint, waypoints[p.Waypoint])), p.Altitude, x, y, hex(legColor))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" font-size="10" text-anchor="middle" fill="%s">%d</text></g>`+"\n",
+			x, y-8, hex(textColor), p.Waypoint+1)
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
@@ -0,0 +1,159 @@
+package missionview_test
+
+import (
+	"encoding/csv"
+	"flightplan2litchimission/missioncsv"
+	"flightplan2litchimission/missionview"
+	"math"
+	"strings"
+	"testing"
+)
+
+// ridge is a terrain model with a 60 m ridge across the first leg of mission() on a
+// 10 m plain
+type ridge struct{}
+
+func (ridge) Elevation(latitude, longitude float64) (float64, error) {
+	if math.Abs(longitude+88.9995) < 0.0001 {
+		return 60, nil
+	}
+	return 10, nil
+}
+
+// TestBuildProfile checks sampling, altitudes above sea level and clearance over the ridge
+func TestBuildProfile(t *testing.T) {
+	options := missionview.DefaultProfileOptions()
+	options.Terrain = ridge{}
+	profile, err := missionview.BuildProfile(mission(), options)
+	if err != nil {
+		t.Fatalf("BuildProfile returned error: %v", err)
+	}
+
+	// Legs of 81.5 and 111.2 m sampled every 10 m or less
+	if len(profile) != 22 {
+		t.Fatalf("expected 22 points, got %d", len(profile))
+	}
+	first, last := profile[0], profile[len(profile)-1]
+	if first.Waypoint != 0 || first.AltitudeASL != 40 || first.Clearance != 30 {
+		t.Errorf("expected waypoint 1 at 40 m, 30 m above the plain, got %+v", first)
+	}
+	if last.Waypoint != 2 || math.Abs(last.Distance-192.6) > 0.1 || last.AltitudeASL != 60 {
+		t.Errorf("expected waypoint 3 at 192.6 m along the route and 60 m, got %+v", last)
+	}
+
+	lowest := first
+	for i, p := range profile {
+		if i > 0 && p.Distance <= profile[i-1].Distance {
+			t.Errorf("point %d: distance %.1f does not increase", i, p.Distance)
+		}
+		if p.Clearance < lowest.Clearance {
+			lowest = p
+		}
+	}
+	if lowest.Waypoint != -1 || lowest.Terrain != 60 || lowest.Clearance >= 0 {
+		t.Errorf("expected a sampled point below the ridge top, got %+v", lowest)
+	}
+
+	// Without terrain there is one point per waypoint and no clearance
+	profile, err = missionview.BuildProfile(mission(), missionview.DefaultProfileOptions())
+	if err != nil || len(profile) != 3 || !math.IsNaN(profile[1].Clearance) || profile[1].Altitude != 40 {
+		t.Errorf("expected three waypoints without terrain, got %+v, %v", profile, err)
+	}
+}
+
+// TestBuildProfileMixedModes checks that Home sets the takeoff elevation and that legs
+// between relative and absolute waypoints are interpolated above sea level
+func TestBuildProfileMixedModes(t *testing.T) {
+	waypoints := mission()
+	waypoints[1].AltitudeMode = 0
+	waypoints[1].Point.Altitude = 150
+
+	options := missionview.DefaultProfileOptions()
+	options.Terrain = ridge{}
+	options.Home = &missioncsv.Point{Latitude: 43, Longitude: -88.9995}
+	profile, err := missionview.BuildProfile(waypoints, options)
+	if err != nil {
+		t.Fatalf("BuildProfile returned error: %v", err)
+	}
+
+	// Taking off from the ridge puts waypoint 1 at 90 m above sea level
+	if first := profile[0]; first.AltitudeASL != 90 || first.Clearance != 80 {
+		t.Errorf("expected waypoint 1 at 90 m, 80 m above the plain, got %+v", first)
+	}
+	for _, p := range profile {
+		if p.Waypoint == 1 {
+			break
+		}
+		if want := 90 + 60*p.Distance/profile[9].Distance; math.Abs(p.AltitudeASL-want) > 1e-6 {
+			t.Errorf("expected %.1f m above sea level at %.1f m, got %.1f", want, p.Distance, p.AltitudeASL)
+		}
+		if math.Abs(p.Altitude-(p.AltitudeASL-60)) > 1e-6 {
+			t.Errorf("expected the altitude above takeoff at %.1f m, got %+v", p.Distance, p)
+		}
+	}
+	if profile[9].Waypoint != 1 || profile[9].AltitudeASL != 150 || profile[9].Altitude != 150 {
+		t.Errorf("expected waypoint 2 at 150 m above sea level, got %+v", profile[9])
+	}
+}
+
+// TestWriteProfileCSV checks the header, waypoint numbers and empty terrain columns
+func TestWriteProfileCSV(t *testing.T) {
+	var b strings.Builder
+	if err := missionview.WriteProfileCSV(&b, mission(), missionview.DefaultProfileOptions()); err != nil {
+		t.Fatalf("WriteProfileCSV returned error: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV: %v", err)
+	}
+	expected := [][]string{
+		{"distance(m)", "waypoint", "latitude", "longitude", "altitude(m)", "altitude_asl(m)", "terrain(m)", "clearance(m)"},
+		{"0.0", "1", "43.0000000", "-89.0000000", "30.0", "", "", ""},
+		{"81.5", "2", "43.0000000", "-88.9990000", "40.0", "", "", ""},
+		{"192.6", "3", "43.0010000", "-88.9990000", "50.0", "", "", ""},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d rows, got %d", len(expected), len(records))
+	}
+	for i := range expected {
+		if strings.Join(records[i], ",") != strings.Join(expected[i], ",") {
+			t.Errorf("row %d: expected %v, got %v", i, expected[i], records[i])
+		}
+	}
+
+	// With terrain, sampled points leave the waypoint column empty
+	b.Reset()
+	options := missionview.DefaultProfileOptions()
+	options.Terrain = ridge{}
+	if err := missionview.WriteProfileCSV(&b, mission(), options); err != nil {
+		t.Fatalf("WriteProfileCSV returned error: %v", err)
+	}
+	lines := strings.Split(b.String(), "\n")
+	if !strings.HasPrefix(lines[1], "0.0,1,43.0000000,-89.0000000,30.0,40.0,10.0,30.0") || !strings.HasPrefix(lines[2], "9.1,,") {
+		t.Errorf("unexpected rows with terrain: %q, %q", lines[1], lines[2])
+	}
+}
+
+// TestWriteProfileSVG checks that the terrain is drawn and points over the ridge marked
+func TestWriteProfileSVG(t *testing.T) {
+	options := missionview.DefaultProfileOptions()
+	options.Terrain = ridge{}
+	options.MinClearance = 15
+
+	var b strings.Builder
+	if err := missionview.WriteProfileSVG(&b, mission(), options); err != nil {
+		t.Fatalf("WriteProfileSVG returned error: %v", err)
+	}
+	svg := b.String()
+	if !strings.Contains(svg, "<polygon") || !strings.Contains(svg, "Altitude (m, above sea level)") {
+		t.Error("expected the terrain and an altitude above sea level")
+	}
+	if n := strings.Count(svg, "m above the terrain"); n != 2 {
+		t.Errorf("expected 2 points marked over the ridge, got %d", n)
+	}
+
+	options.Step = 0
+	if err := missionview.WriteProfileSVG(&b, mission(), options); err == nil {
+		t.Error("expected an error for a zero step")
+	}
+}
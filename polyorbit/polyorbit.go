@@ -1,4 +1,4 @@
-// Package polyorbit provides utilities for creating regular polygons for drone flight paths.
+// Package polyorbit provides utilities for creating regular polygons and orbits for drone flight paths.
 package polyorbit
 
 import (
@@ -0,0 +1,135 @@
+package polyorbit
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"log/slog"
+	"math"
+)
+
+// minPointsPerTurn is the fewest waypoints per turn that still describe a circle
+const minPointsPerTurn = 3
+
+// curveFraction is the share of the distance to the next waypoint used as curve size,
+// kept under one half so the curves of neighbouring waypoints never overlap
+const curveFraction = 0.45
+
+// SpiralOptions configures a continuous helical orbit around a structure
+type SpiralOptions struct {
+	// Turns is the number of full orbits flown between the start and end altitudes
+	Turns float64
+
+	// PointsPerTurn is the preferred number of waypoints per orbit. It is reduced
+	// when needed to keep the mission within the Litchi waypoint limit.
+	PointsPerTurn int
+
+	// StartAltitude and EndAltitude are the altitudes above the takeoff point in meters
+	// at the beginning and end of the spiral. An end above the start ascends.
+	StartAltitude float64
+	EndAltitude   float64
+
+	// StartDiameter and EndDiameter are the orbit diameters in meters at the beginning
+	// and end of the spiral. An EndDiameter of 0 keeps StartDiameter throughout.
+	StartDiameter float64
+	EndDiameter   float64
+
+	// Heading selects how waypoint headings are computed. An empty mode faces the
+	// center, rather than the "course" that HeadingStrategy.Apply uses; the "poi"
+	// mode uses the center as the point of interest.
+	Heading fp2lm.HeadingStrategy
+}
+
+// DefaultSpiralOptions returns recommended default options for a spiral orbit
+//
+// The default options are:
+// - Turns: 4
+// - PointsPerTurn: 16
+// - StartAltitude: 10 meters, EndAltitude: 50 meters (ascending)
+// - StartDiameter: 40 meters, EndDiameter: 0 (constant diameter)
+// - Heading: empty mode (face the center)
+func DefaultSpiralOptions() *SpiralOptions {
+	return &SpiralOptions{
+		Turns:         4,
+		PointsPerTurn: 16,
+		StartAltitude: 10,
+		EndAltitude:   50,
+		StartDiameter: 40,
+		EndDiameter:   0,
+		Heading:       fp2lm.HeadingStrategy{},
+	}
+}
+
+// Spiral generates a helix of waypoints around a structure
+//
+// Parameters:
+//   - center: The structure's axis, with Altitude set to the height on the structure
+//     the camera should aim at (relative to the takeoff point)
+//   - options: Configuration options for the spiral
+//
+// Waypoints are spread evenly along the helix, flown clockwise, facing the center (or
+// as Heading selects) with gimbal pitch aimed at center.Altitude as in Stack. Each
+// intermediate waypoint gets a curve size so Litchi flies one smooth curve instead of
+// stopping at every point.
+// If Turns * PointsPerTurn would exceed missioncsv.MaxLitchiWaypoints, fewer points
+// per turn are used.
+func Spiral(center missioncsv.POI, options *SpiralOptions) ([]*missioncsv.LitchiWaypoint, error) {
+	if options == nil {
+		options = DefaultSpiralOptions()
+	}
+
+	if options.Turns <= 0 {
+		return nil, fmt.Errorf("turns must be positive, got %.2f", options.Turns)
+	}
+	if options.PointsPerTurn < minPointsPerTurn {
+		return nil, fmt.Errorf("points per turn must be at least %d, got %d", minPointsPerTurn, options.PointsPerTurn)
+	}
+	if options.StartAltitude <= 0 || options.EndAltitude <= 0 {
+		return nil, fmt.Errorf("altitudes must be positive, got %.1f to %.1f", options.StartAltitude, options.EndAltitude)
+	}
+	endDiameter := options.EndDiameter
+	if endDiameter == 0 {
+		endDiameter = options.StartDiameter
+	}
+	if options.StartDiameter <= 0 || endDiameter < 0 {
+		return nil, fmt.Errorf("diameters must be positive, got %.1f to %.1f", options.StartDiameter, endDiameter)
+	}
+	if err := options.Heading.Validate(&center); err != nil {
+		return nil, err
+	}
+
+	// Fit the helix within the waypoint limit, keeping one waypoint for the end point
+	pointsPerTurn := options.PointsPerTurn
+	if steps := int(math.Ceil(options.Turns * float64(pointsPerTurn))); steps+1 > missioncsv.MaxLitchiWaypoints {
+		pointsPerTurn = int(float64(missioncsv.MaxLitchiWaypoints-1) / options.Turns)
+		if pointsPerTurn < minPointsPerTurn {
+			return nil, fmt.Errorf("%.1f turns need at least %d waypoints, more than the Litchi limit of %d",
+				options.Turns, int(math.Ceil(options.Turns*minPointsPerTurn))+1, missioncsv.MaxLitchiWaypoints)
+		}
+		slog.Info("Reduced points per turn to fit the Litchi waypoint limit",
+			"requested", options.PointsPerTurn, "used", pointsPerTurn)
+	}
+	steps := int(math.Ceil(options.Turns * float64(pointsPerTurn)))
+
+	waypoints := make([]*missioncsv.LitchiWaypoint, 0, steps+1)
+	for i := 0; i <= steps; i++ {
+		fraction := float64(i) / float64(steps)
+		bearing := math.Mod(360*options.Turns*fraction, 360)
+		radius := (options.StartDiameter + (endDiameter-options.StartDiameter)*fraction) / 2
+		altitude := options.StartAltitude + (options.EndAltitude-options.StartAltitude)*fraction
+		pitch := aimPitch(center.Altitude-altitude, radius)
+
+		wp := orbitWaypoint(center, bearing, radius, altitude, pitch)
+		if i > 0 && i < steps {
+			// Chord to the neighbouring waypoints at this radius
+			chord := 2 * radius * math.Sin(math.Pi*options.Turns/float64(steps))
+			wp.CurveSize = float32(chord * curveFraction)
+		}
+		waypoints = append(waypoints, wp)
+	}
+	if err := applyHeading(waypoints, center, options.Heading); err != nil {
+		return nil, err
+	}
+
+	return waypoints, nil
+}
@@ -0,0 +1,53 @@
+package polyorbit_test
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"flightplan2litchimission/polyorbit"
+	"testing"
+)
+
+// TestSpiral checks the helix endpoints and smooth curves between them
+func TestSpiral(t *testing.T) {
+	center := missioncsv.POI{Latitude: 43.0, Longitude: -89.0, Altitude: 30}
+
+	waypoints, err := polyorbit.Spiral(center, polyorbit.DefaultSpiralOptions())
+	if err != nil {
+		t.Fatalf("Spiral returned error: %v", err)
+	}
+	if len(waypoints) != 4*16+1 {
+		t.Fatalf("expected %d waypoints, got %d", 4*16+1, len(waypoints))
+	}
+
+	first, last := waypoints[0], waypoints[len(waypoints)-1]
+	if first.Point.Altitude != 10 || last.Point.Altitude != 50 {
+		t.Errorf("expected altitudes from 10 to 50, got %.1f to %.1f", first.Point.Altitude, last.Point.Altitude)
+	}
+	if first.CurveSize != 0 || last.CurveSize != 0 {
+		t.Errorf("expected no curves at the ends, got %.1f and %.1f", first.CurveSize, last.CurveSize)
+	}
+	for i := 1; i < len(waypoints)-1; i++ {
+		if waypoints[i].CurveSize <= 0 {
+			t.Fatalf("waypoint %d: expected a curve size, got %.1f", i, waypoints[i].CurveSize)
+		}
+	}
+}
+
+// TestSpiralWaypointLimit checks that points per turn adapt to the Litchi limit
+func TestSpiralWaypointLimit(t *testing.T) {
+	options := polyorbit.DefaultSpiralOptions()
+	options.Turns = 10
+	options.PointsPerTurn = 24
+
+	waypoints, err := polyorbit.Spiral(missioncsv.POI{Latitude: 43.0, Longitude: -89.0}, options)
+	if err != nil {
+		t.Fatalf("Spiral returned error: %v", err)
+	}
+	if len(waypoints) > missioncsv.MaxLitchiWaypoints {
+		t.Errorf("expected at most %d waypoints, got %d", missioncsv.MaxLitchiWaypoints, len(waypoints))
+	}
+
+	options.Turns = 40
+	if _, err := polyorbit.Spiral(missioncsv.POI{}, options); err == nil {
+		t.Error("expected an error when even the minimum points per turn do not fit")
+	}
+}
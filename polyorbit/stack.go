@@ -0,0 +1,148 @@
+package polyorbit
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+)
+
+// Gimbal pitch limits in degrees supported by Litchi
+const (
+	minGimbalPitch = -90
+	maxGimbalPitch = 30
+)
+
+// StackOptions configures a stack of orbits around a vertical structure
+type StackOptions struct {
+	// Sides is the number of waypoints in each ring
+	Sides int
+
+	// Diameter is the distance across each ring through its center in meters
+	Diameter float64
+
+	// MinAltitude and MaxAltitude are the altitudes of the lowest and highest rings
+	// above the takeoff point in meters
+	MinAltitude float64
+	MaxAltitude float64
+
+	// Rings is the number of rings, spaced evenly between MinAltitude and MaxAltitude
+	Rings int
+
+	// Heading selects how waypoint headings are computed. An empty mode faces the
+	// center, rather than the "course" that HeadingStrategy.Apply uses; the "poi"
+	// mode uses the center as the point of interest.
+	Heading fp2lm.HeadingStrategy
+}
+
+// DefaultStackOptions returns recommended default options for an orbit stack
+//
+// The default options are:
+// - Sides: 12 waypoints per ring
+// - Diameter: 40 meters
+// - MinAltitude: 10 meters, MaxAltitude: 40 meters
+// - Rings: 4
+// - Heading: empty mode (face the center)
+func DefaultStackOptions() *StackOptions {
+	return &StackOptions{
+		Sides:       12,
+		Diameter:    40,
+		MinAltitude: 10,
+		MaxAltitude: 40,
+		Rings:       4,
+		Heading:     fp2lm.HeadingStrategy{},
+	}
+}
+
+// Stack generates rings of waypoints at increasing altitudes around a structure
+//
+// Parameters:
+//   - center: The structure's axis, with Altitude set to the height on the structure
+//     the camera should aim at (relative to the takeoff point)
+//   - options: Configuration options for the stack
+//
+// Rings are flown clockwise from the lowest to the highest. Every waypoint faces the
+// center unless Heading selects another mode, and its gimbal pitch is aimed at
+// center.Altitude from the ring's height, so rings below it look up and rings above
+// it look down. The POI fields are filled in with center so the mission can also be
+// flown in Litchi's POI mode.
+func Stack(center missioncsv.POI, options *StackOptions) ([]*missioncsv.LitchiWaypoint, error) {
+	if options == nil {
+		options = DefaultStackOptions()
+	}
+
+	if options.Sides < 3 {
+		return nil, fmt.Errorf("rings must have at least 3 sides, got %d", options.Sides)
+	}
+	if options.Diameter <= 0 {
+		return nil, fmt.Errorf("diameter must be positive, got %.1f", options.Diameter)
+	}
+	if options.Rings < 1 {
+		return nil, fmt.Errorf("ring count must be at least 1, got %d", options.Rings)
+	}
+	if options.MinAltitude <= 0 || options.MaxAltitude < options.MinAltitude {
+		return nil, fmt.Errorf("altitudes must be positive with minimum not above maximum, got %.1f to %.1f",
+			options.MinAltitude, options.MaxAltitude)
+	}
+	if count := options.Sides * options.Rings; count > missioncsv.MaxLitchiWaypoints {
+		return nil, fmt.Errorf("%d rings of %d sides need %d waypoints, more than the Litchi limit of %d",
+			options.Rings, options.Sides, count, missioncsv.MaxLitchiWaypoints)
+	}
+	if err := options.Heading.Validate(&center); err != nil {
+		return nil, err
+	}
+
+	radius := options.Diameter / 2
+	var waypoints []*missioncsv.LitchiWaypoint
+	for ring := 0; ring < options.Rings; ring++ {
+		altitude := options.MinAltitude
+		if options.Rings > 1 {
+			altitude += (options.MaxAltitude - options.MinAltitude) * float64(ring) / float64(options.Rings-1)
+		}
+		pitch := aimPitch(center.Altitude-altitude, radius)
+
+		for side := 0; side < options.Sides; side++ {
+			bearing := 360 * float64(side) / float64(options.Sides)
+			waypoints = append(waypoints, orbitWaypoint(center, bearing, radius, altitude, pitch))
+		}
+	}
+	if err := applyHeading(waypoints, center, options.Heading); err != nil {
+		return nil, err
+	}
+
+	return waypoints, nil
+}
+
+// orbitWaypoint creates a waypoint at the given bearing and distance from center,
+// facing center and carrying it as the point of interest
+func orbitWaypoint(center missioncsv.POI, bearing, distance, altitude, pitch float64) *missioncsv.LitchiWaypoint {
+	lat, lon := fp2lm.Destination(center.Latitude, center.Longitude, bearing, distance)
+
+	wp := missioncsv.NewLitchiWaypoint()
+	wp.Point.Latitude = lat
+	wp.Point.Longitude = lon
+	wp.Point.Altitude = altitude
+	wp.AltitudeMode = 1 // Relative (AGL)
+	wp.Heading = float32(fp2lm.CalculateBearing(lat, lon, center.Latitude, center.Longitude))
+	wp.GimbalMode = 2 // Interpolate between waypoint pitches
+	wp.GimbalPitch = float32(pitch)
+	wp.POI = center
+	wp.POIAltMode = 1 // Relative (AGL)
+	return wp
+}
+
+// applyHeading applies a heading strategy to orbit waypoints, which already face the
+// center when the mode is empty
+func applyHeading(waypoints []*missioncsv.LitchiWaypoint, center missioncsv.POI, heading fp2lm.HeadingStrategy) error {
+	if heading.Mode == "" {
+		return nil
+	}
+	return heading.Apply(waypoints, &center)
+}
+
+// aimPitch returns the gimbal pitch in degrees needed to look at a point rise meters
+// above the camera and distance meters away, clamped to the range Litchi supports
+func aimPitch(rise, distance float64) float64 {
+	pitch := math.Atan2(rise, distance) * 180 / math.Pi
+	return math.Max(minGimbalPitch, math.Min(maxGimbalPitch, pitch))
+}
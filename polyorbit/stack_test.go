@@ -0,0 +1,71 @@
+package polyorbit_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"flightplan2litchimission/polyorbit"
+	"math"
+	"testing"
+)
+
+// TestStack checks ring altitudes, headings toward the center and aimed pitches
+func TestStack(t *testing.T) {
+	center := missioncsv.POI{Latitude: 43.0, Longitude: -89.0, Altitude: 20}
+
+	waypoints, err := polyorbit.Stack(center, polyorbit.DefaultStackOptions())
+	if err != nil {
+		t.Fatalf("Stack returned error: %v", err)
+	}
+	if len(waypoints) != 48 {
+		t.Fatalf("expected 48 waypoints, got %d", len(waypoints))
+	}
+
+	// The first waypoint of each ring sits due north of the center, looking south
+	for ring, altitude := range []float64{10, 20, 30, 40} {
+		wp := waypoints[ring*12]
+		if wp.Point.Altitude != altitude {
+			t.Errorf("ring %d: expected altitude %.0f, got %.1f", ring, altitude, wp.Point.Altitude)
+		}
+		if math.Abs(float64(wp.Heading)-180) > 0.1 {
+			t.Errorf("ring %d: expected heading 180, got %.1f", ring, wp.Heading)
+		}
+		want := math.Atan2(20-altitude, 20) * 180 / math.Pi
+		if math.Abs(float64(wp.GimbalPitch)-want) > 0.1 {
+			t.Errorf("ring %d: expected pitch %.1f, got %.1f", ring, want, wp.GimbalPitch)
+		}
+		if wp.POI != center {
+			t.Errorf("ring %d: expected POI %+v, got %+v", ring, center, wp.POI)
+		}
+	}
+}
+
+// TestStackTooManyWaypoints checks that stacks beyond the Litchi limit are rejected
+func TestStackTooManyWaypoints(t *testing.T) {
+	options := polyorbit.DefaultStackOptions()
+	options.Rings = 10
+	if _, err := polyorbit.Stack(missioncsv.POI{}, options); err == nil {
+		t.Error("expected an error for 120 waypoints")
+	}
+}
+
+// TestStackHeading checks that a heading strategy replaces facing the center
+func TestStackHeading(t *testing.T) {
+	center := missioncsv.POI{Latitude: 43.0, Longitude: -89.0, Altitude: 20}
+	options := polyorbit.DefaultStackOptions()
+	options.Heading = fp2lm.HeadingStrategy{Mode: "fixed", Azimuth: 45}
+
+	waypoints, err := polyorbit.Stack(center, options)
+	if err != nil {
+		t.Fatalf("Stack returned error: %v", err)
+	}
+	for i, wp := range waypoints {
+		if wp.Heading != 45 {
+			t.Errorf("waypoint %d: expected heading 45, got %.1f", i, wp.Heading)
+		}
+	}
+
+	options.Heading.Mode = "sideways"
+	if _, err := polyorbit.Stack(center, options); err == nil {
+		t.Error("expected an error for an unknown heading mode")
+	}
+}
@@ -0,0 +1,182 @@
+package survey
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+)
+
+// minMiter limits how far offset legs can spike outward at sharp bends in the centerline
+const minMiter = 0.25
+
+// CorridorOptions configures a corridor survey along a polyline
+type CorridorOptions struct {
+	// Width is the total width of the corridor to cover in meters
+	Width float64
+
+	// Altitude is the flight altitude above the takeoff point in meters
+	Altitude float64
+
+	// Camera describes the imaging geometry used to space legs and photos
+	Camera Camera
+
+	// SideOverlap is the fraction of overlap between adjacent legs (0 to 1)
+	SideOverlap float64
+
+	// FrontOverlap is the fraction of overlap between consecutive photos (0 to 1)
+	FrontOverlap float64
+
+	// GimbalPitch is the camera angle in degrees (between -90 and 0)
+	GimbalPitch float64
+
+	// Heading selects how waypoint headings are computed. An empty mode faces along
+	// the legs; an "offset" of 90 or -90 points the camera sideways at the corridor.
+	Heading fp2lm.HeadingStrategy
+}
+
+// DefaultCorridorOptions returns recommended default options for a corridor survey
+//
+// The default options are:
+// - Width: 50 meters
+// - Altitude: 60 meters
+// - Camera: DefaultCamera()
+// - SideOverlap: 0.7, FrontOverlap: 0.8
+// - GimbalPitch: -90 degrees (straight down)
+// - Heading: empty mode (face along the legs)
+func DefaultCorridorOptions() *CorridorOptions {
+	return &CorridorOptions{
+		Width:        50,
+		Altitude:     60,
+		Camera:       DefaultCamera(),
+		SideOverlap:  0.7,
+		FrontOverlap: 0.8,
+		GimbalPitch:  -90,
+		Heading:      fp2lm.HeadingStrategy{},
+	}
+}
+
+// Corridor generates parallel legs offset from a centerline such as a road, pipeline
+// or power line
+//
+// Parameters:
+//   - centerline: Vertices of the polyline to follow (at least 2)
+//   - options: Configuration options for the survey
+//
+// The number of legs is the smallest that covers Width with the camera footprint at the
+// requested side overlap. Legs are spread symmetrically about the centerline and flown
+// back and forth from one edge of the corridor to the other, with a waypoint at every
+// bend. Long corridors can exceed missioncsv.MaxLitchiWaypoints; use Split to break
+// the result into flyable missions.
+func Corridor(centerline []Coordinate, options *CorridorOptions) ([]*missioncsv.LitchiWaypoint, error) {
+	if options == nil {
+		options = DefaultCorridorOptions()
+	}
+
+	if len(centerline) < 2 {
+		return nil, fmt.Errorf("centerline must have at least 2 vertices, got %d", len(centerline))
+	}
+	if options.Width <= 0 {
+		return nil, fmt.Errorf("corridor width must be positive, got %.1f", options.Width)
+	}
+	if options.Altitude <= 0 {
+		return nil, fmt.Errorf("altitude must be positive, got %.1f", options.Altitude)
+	}
+	if err := options.Camera.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateOverlap("side", options.SideOverlap); err != nil {
+		return nil, err
+	}
+	if err := validateOverlap("front", options.FrontOverlap); err != nil {
+		return nil, err
+	}
+	if err := validatePitch(options.GimbalPitch); err != nil {
+		return nil, err
+	}
+	if err := options.Heading.Validate(nil); err != nil {
+		return nil, err
+	}
+
+	frame := newLocalFrame(centerline)
+	var line [][2]float64
+	for _, c := range centerline {
+		x, y := frame.toXY(c)
+		// Drop repeated vertices, which have no direction to offset from
+		if n := len(line); n > 0 && math.Hypot(x-line[n-1][0], y-line[n-1][1]) < 1e-3 {
+			continue
+		}
+		line = append(line, [2]float64{x, y})
+	}
+	if len(line) < 2 {
+		return nil, fmt.Errorf("centerline has no length")
+	}
+
+	footprintWidth, footprintHeight := options.Camera.Footprint(options.Altitude)
+	spacing := options.Camera.lineSpacing(options.Altitude, options.SideOverlap)
+	legCount := 1
+	if options.Width > footprintWidth {
+		legCount += int(math.Ceil((options.Width - footprintWidth) / spacing))
+	}
+	photoInterval := footprintHeight * (1 - options.FrontOverlap)
+
+	var waypoints []*missioncsv.LitchiWaypoint
+	for leg := 0; leg < legCount; leg++ {
+		offset := (float64(leg) - float64(legCount-1)/2) * spacing
+		path := offsetPolyline(line, offset)
+		// Fly every other leg in reverse so each one starts where the last one ended
+		if leg%2 == 1 {
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+		}
+		for _, p := range path {
+			wp := newWaypoint(frame.toCoordinate(p[0], p[1]), options.Altitude, options.GimbalPitch)
+			wp.PhotoDistInterval = float32(photoInterval)
+			waypoints = append(waypoints, wp)
+		}
+	}
+	if err := options.Heading.Apply(waypoints, nil); err != nil {
+		return nil, err
+	}
+
+	return waypoints, nil
+}
+
+// offsetPolyline returns a copy of line shifted sideways by offset meters, positive to
+// the left of the direction of travel. Interior vertices are mitered so each offset
+// segment stays parallel to the original.
+func offsetPolyline(line [][2]float64, offset float64) [][2]float64 {
+	normals := make([][2]float64, len(line)-1)
+	for i := range normals {
+		dx := line[i+1][0] - line[i][0]
+		dy := line[i+1][1] - line[i][1]
+		length := math.Hypot(dx, dy)
+		normals[i] = [2]float64{-dy / length, dx / length}
+	}
+
+	path := make([][2]float64, len(line))
+	for i, p := range line {
+		var n [2]float64
+		scale := 1.0
+		switch {
+		case i == 0:
+			n = normals[0]
+		case i == len(line)-1:
+			n = normals[len(normals)-1]
+		default:
+			prev, next := normals[i-1], normals[i]
+			n = [2]float64{prev[0] + next[0], prev[1] + next[1]}
+			length := math.Hypot(n[0], n[1])
+			if length < 1e-9 {
+				// The centerline doubles back on itself; offset along the incoming normal
+				n = prev
+			} else {
+				n = [2]float64{n[0] / length, n[1] / length}
+				scale = 1 / math.Max(n[0]*next[0]+n[1]*next[1], minMiter)
+			}
+		}
+		path[i] = [2]float64{p[0] + n[0]*offset*scale, p[1] + n[1]*offset*scale}
+	}
+	return path
+}
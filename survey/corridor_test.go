@@ -0,0 +1,101 @@
+package survey_test
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"flightplan2litchimission/survey"
+	"math"
+	"testing"
+)
+
+// TestCorridor checks leg count and back-and-forth ordering along a bent centerline
+func TestCorridor(t *testing.T) {
+	centerline := []survey.Coordinate{
+		{Latitude: 43.0000, Longitude: -89.0000},
+		{Latitude: 43.0000, Longitude: -88.9950},
+		{Latitude: 43.0030, Longitude: -88.9920},
+	}
+
+	options := survey.DefaultCorridorOptions()
+	options.Width = 150
+
+	waypoints, err := survey.Corridor(centerline, options)
+	if err != nil {
+		t.Fatalf("Corridor returned error: %v", err)
+	}
+
+	// 90 m footprint and 27 m spacing need 1 + ceil(60/27) = 4 legs
+	if len(waypoints) != 4*len(centerline) {
+		t.Fatalf("expected %d waypoints, got %d", 4*len(centerline), len(waypoints))
+	}
+
+	// The first leg flies east along the first segment, the second flies back west
+	if h := float64(waypoints[0].Heading); math.Abs(h-90) > 1 {
+		t.Errorf("expected first leg heading 90, got %.1f", h)
+	}
+	if h := float64(waypoints[len(centerline)+1].Heading); math.Abs(h-270) > 1 {
+		t.Errorf("expected second leg heading 270, got %.1f", h)
+	}
+
+	// Legs are offset symmetrically: the first starts south of the centerline
+	if waypoints[0].Point.Latitude >= centerline[0].Latitude {
+		t.Errorf("expected the first leg south of the centerline, got %.7f", waypoints[0].Point.Latitude)
+	}
+}
+
+// TestCorridorNarrow checks that a corridor narrower than the footprint is a single leg
+func TestCorridorNarrow(t *testing.T) {
+	centerline := []survey.Coordinate{
+		{Latitude: 43.0000, Longitude: -89.0000},
+		{Latitude: 43.0010, Longitude: -89.0000},
+	}
+	options := survey.DefaultCorridorOptions()
+	options.Width = 20
+
+	waypoints, err := survey.Corridor(centerline, options)
+	if err != nil {
+		t.Fatalf("Corridor returned error: %v", err)
+	}
+	if len(waypoints) != 2 {
+		t.Fatalf("expected 2 waypoints, got %d", len(waypoints))
+	}
+	if math.Abs(waypoints[0].Point.Longitude-centerline[0].Longitude) > 1e-9 {
+		t.Errorf("expected a single leg on the centerline, got longitude %.7f", waypoints[0].Point.Longitude)
+	}
+}
+
+// TestCorridorSplit checks that splitting a long corridor keeps every leg, including
+// the ones across each split
+func TestCorridorSplit(t *testing.T) {
+	// A winding centerline of 40 vertices flown as 4 legs gives 160 waypoints
+	var centerline []survey.Coordinate
+	for i := 0; i < 40; i++ {
+		centerline = append(centerline, survey.Coordinate{
+			Latitude:  43 + 0.0005*float64(i%2),
+			Longitude: -89 + 0.001*float64(i),
+		})
+	}
+	options := survey.DefaultCorridorOptions()
+	options.Width = 150
+
+	waypoints, err := survey.Corridor(centerline, options)
+	if err != nil {
+		t.Fatalf("Corridor returned error: %v", err)
+	}
+	missions := survey.Split(waypoints)
+	if len(missions) != 2 {
+		t.Fatalf("expected 2 missions, got %d", len(missions))
+	}
+
+	route := assertLegsFlown(t, missions)
+	if len(route) != len(waypoints) {
+		t.Fatalf("expected the missions to rebuild %d waypoints, got %d", len(waypoints), len(route))
+	}
+	for i, wp := range waypoints {
+		if route[i] != wp.Point {
+			t.Errorf("waypoint %d is out of place after splitting", i+1)
+		}
+	}
+	if len(missions[1]) != len(waypoints)-missioncsv.MaxLitchiWaypoints+1 {
+		t.Errorf("expected the second mission to repeat the end of the first, got %d waypoints", len(missions[1]))
+	}
+}
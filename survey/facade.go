@@ -0,0 +1,149 @@
+package survey
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+)
+
+// FacadeOptions configures a vertical scan of a building facade
+type FacadeOptions struct {
+	// Standoff is the horizontal distance from the wall in meters. Positive values fly
+	// to the left of the wall when looking from its start to its end, negative values
+	// to the right.
+	Standoff float64
+
+	// MinAltitude and MaxAltitude bound the rows of waypoints above the takeoff point in meters
+	MinAltitude float64
+	MaxAltitude float64
+
+	// Camera describes the imaging geometry used to space rows and columns
+	Camera Camera
+
+	// HorizontalOverlap is the fraction of overlap between neighbouring columns (0 to 1)
+	HorizontalOverlap float64
+
+	// VerticalOverlap is the fraction of overlap between neighbouring rows (0 to 1)
+	VerticalOverlap float64
+
+	// GimbalPitch is the camera angle in degrees (between -90 and 0)
+	GimbalPitch float64
+
+	// Heading selects how waypoint headings are computed. An empty mode faces the
+	// wall, rather than the "course" that HeadingStrategy.Apply uses.
+	Heading fp2lm.HeadingStrategy
+}
+
+// DefaultFacadeOptions returns recommended default options for a facade scan
+//
+// The default options are:
+// - Standoff: 10 meters
+// - MinAltitude: 5 meters, MaxAltitude: 30 meters
+// - Camera: DefaultCamera()
+// - HorizontalOverlap: 0.7, VerticalOverlap: 0.7
+// - GimbalPitch: 0 degrees (level with the horizon)
+// - Heading: empty mode (face the wall)
+func DefaultFacadeOptions() *FacadeOptions {
+	return &FacadeOptions{
+		Standoff:          10,
+		MinAltitude:       5,
+		MaxAltitude:       30,
+		Camera:            DefaultCamera(),
+		HorizontalOverlap: 0.7,
+		VerticalOverlap:   0.7,
+		GimbalPitch:       0,
+		Heading:           fp2lm.HeadingStrategy{},
+	}
+}
+
+// Facade generates rows of waypoints stacked along a wall with the camera facing it
+//
+// Parameters:
+//   - start, end: The ends of the wall at ground level
+//   - options: Configuration options for the scan
+//
+// Rows are spaced evenly from MinAltitude to MaxAltitude and columns evenly along the
+// wall so that neighbouring photos meet the requested overlaps at the standoff distance.
+// Rows are flown back and forth from the bottom up, and every waypoint takes a photo
+// with its heading perpendicular to the wall unless Heading selects another mode.
+// Large facades can exceed missioncsv.MaxLitchiWaypoints; use Split to break the
+// result into flyable missions.
+func Facade(start, end Coordinate, options *FacadeOptions) ([]*missioncsv.LitchiWaypoint, error) {
+	if options == nil {
+		options = DefaultFacadeOptions()
+	}
+
+	if options.Standoff == 0 {
+		return nil, fmt.Errorf("standoff distance must not be zero")
+	}
+	if options.MinAltitude <= 0 || options.MaxAltitude < options.MinAltitude {
+		return nil, fmt.Errorf("altitudes must be positive with minimum not above maximum, got %.1f to %.1f",
+			options.MinAltitude, options.MaxAltitude)
+	}
+	if err := options.Camera.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateOverlap("horizontal", options.HorizontalOverlap); err != nil {
+		return nil, err
+	}
+	if err := validateOverlap("vertical", options.VerticalOverlap); err != nil {
+		return nil, err
+	}
+	if err := validatePitch(options.GimbalPitch); err != nil {
+		return nil, err
+	}
+	if err := options.Heading.Validate(nil); err != nil {
+		return nil, err
+	}
+
+	frame := newLocalFrame([]Coordinate{start, end})
+	x1, y1 := frame.toXY(start)
+	x2, y2 := frame.toXY(end)
+	length := math.Hypot(x2-x1, y2-y1)
+	if length < 1e-3 {
+		return nil, fmt.Errorf("wall has no length")
+	}
+	// Unit vector along the wall and its left-hand normal
+	dx, dy := (x2-x1)/length, (y2-y1)/length
+	nx, ny := -dy, dx
+
+	// The camera footprint on the wall at the standoff distance sets the spacing
+	standoff := math.Abs(options.Standoff)
+	footprintWidth, footprintHeight := options.Camera.Footprint(standoff)
+	columns := 1 + int(math.Ceil(length/(footprintWidth*(1-options.HorizontalOverlap))))
+	height := options.MaxAltitude - options.MinAltitude
+	rows := 1 + int(math.Ceil(height/(footprintHeight*(1-options.VerticalOverlap))))
+
+	var waypoints []*missioncsv.LitchiWaypoint
+	for row := 0; row < rows; row++ {
+		altitude := options.MinAltitude
+		if rows > 1 {
+			altitude += height * float64(row) / float64(rows-1)
+		}
+		for i := 0; i < columns; i++ {
+			column := i
+			// Fly every other row in reverse so each one starts above where the last one ended
+			if row%2 == 1 {
+				column = columns - 1 - i
+			}
+			along := length * float64(column) / float64(columns-1)
+			wallX, wallY := x1+dx*along, y1+dy*along
+			flightX, flightY := wallX+nx*options.Standoff, wallY+ny*options.Standoff
+
+			wall := frame.toCoordinate(wallX, wallY)
+			position := frame.toCoordinate(flightX, flightY)
+			wp := newWaypoint(position, altitude, options.GimbalPitch)
+			wp.Heading = float32(fp2lm.CalculateBearing(position.Latitude, position.Longitude,
+				wall.Latitude, wall.Longitude))
+			waypoints = append(waypoints, wp)
+		}
+	}
+	if options.Heading.Mode != "" {
+		if err := options.Heading.Apply(waypoints, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return waypoints, nil
+}
@@ -0,0 +1,73 @@
+package survey_test
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/survey"
+	"math"
+	"testing"
+)
+
+// TestFacade checks that waypoints face the wall and cover the altitude range
+func TestFacade(t *testing.T) {
+	start := survey.Coordinate{Latitude: 43.0000, Longitude: -89.0000}
+	end := survey.Coordinate{Latitude: 43.0000, Longitude: -88.9995} // ~40 m east
+
+	waypoints, err := survey.Facade(start, end, survey.DefaultFacadeOptions())
+	if err != nil {
+		t.Fatalf("Facade returned error: %v", err)
+	}
+	if len(waypoints) == 0 {
+		t.Fatal("expected waypoints")
+	}
+
+	minAlt, maxAlt := math.Inf(1), math.Inf(-1)
+	for i, wp := range waypoints {
+		// Standing north of an east-running wall means looking south
+		if math.Abs(float64(wp.Heading)-180) > 0.5 {
+			t.Errorf("waypoint %d: expected heading 180, got %.1f", i, wp.Heading)
+		}
+		if wp.GimbalPitch != 0 {
+			t.Errorf("waypoint %d: expected pitch 0, got %.1f", i, wp.GimbalPitch)
+		}
+		if wp.Point.Latitude <= start.Latitude {
+			t.Errorf("waypoint %d: expected to be north of the wall", i)
+		}
+		minAlt = math.Min(minAlt, wp.Point.Altitude)
+		maxAlt = math.Max(maxAlt, wp.Point.Altitude)
+	}
+	if minAlt != 5 || maxAlt != 30 {
+		t.Errorf("expected altitudes from 5 to 30, got %.1f to %.1f", minAlt, maxAlt)
+	}
+
+	// Rows alternate direction, so the second row starts above the end of the first
+	firstRowEnd := 0
+	for waypoints[firstRowEnd+1].Point.Altitude == minAlt {
+		firstRowEnd++
+	}
+	if waypoints[firstRowEnd].Point.Longitude != waypoints[firstRowEnd+1].Point.Longitude {
+		t.Errorf("expected the second row to start above the end of the first")
+	}
+}
+
+// TestFacadeHeading checks that a heading strategy replaces facing the wall
+func TestFacadeHeading(t *testing.T) {
+	start := survey.Coordinate{Latitude: 43.0000, Longitude: -89.0000}
+	end := survey.Coordinate{Latitude: 43.0000, Longitude: -88.9995}
+	options := survey.DefaultFacadeOptions()
+	options.Heading = fp2lm.HeadingStrategy{Mode: "fixed", Azimuth: 90}
+
+	waypoints, err := survey.Facade(start, end, options)
+	if err != nil {
+		t.Fatalf("Facade returned error: %v", err)
+	}
+	for i, wp := range waypoints {
+		if wp.Heading != 90 {
+			t.Errorf("waypoint %d: expected heading 90, got %.1f", i, wp.Heading)
+		}
+	}
+
+	options.Heading.Mode = "poi"
+	if _, err := survey.Facade(start, end, options); err == nil {
+		t.Error("expected an error for the poi mode without a point of interest")
+	}
+}
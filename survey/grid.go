@@ -0,0 +1,263 @@
+package survey
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+	"math"
+)
+
+// GridOptions configures a lawnmower survey over a polygon
+type GridOptions struct {
+	// Altitude is the flight altitude above the takeoff point in meters
+	Altitude float64
+
+	// Angle is the direction of the flight lines in degrees from North
+	Angle float64
+
+	// Camera describes the imaging geometry used to space lines and photos
+	Camera Camera
+
+	// SideOverlap is the fraction of overlap between adjacent flight lines (0 to 1)
+	SideOverlap float64
+
+	// FrontOverlap is the fraction of overlap between consecutive photos (0 to 1)
+	FrontOverlap float64
+
+	// GimbalPitch is the camera angle in degrees (between -90 and 0) for the first pass
+	GimbalPitch float64
+
+	// Crosshatch adds a second pass with flight lines perpendicular to the first
+	Crosshatch bool
+
+	// CrossGimbalPitch is the camera angle in degrees (between -90 and 0) for the
+	// crosshatch pass. Oblique values such as -60 give better facade coverage
+	// for 3D reconstruction.
+	CrossGimbalPitch float64
+
+	// Heading selects how waypoint headings are computed within each pass.
+	// An empty mode faces along the flight lines.
+	Heading fp2lm.HeadingStrategy
+}
+
+// DefaultGridOptions returns recommended default options for a grid survey
+//
+// The default options are:
+// - Altitude: 60 meters
+// - Angle: 0 degrees (North-South lines)
+// - Camera: DefaultCamera()
+// - SideOverlap: 0.7, FrontOverlap: 0.8
+// - GimbalPitch and CrossGimbalPitch: -90 degrees (straight down)
+// - Crosshatch: false
+// - Heading: empty mode (face along the flight lines)
+func DefaultGridOptions() *GridOptions {
+	return &GridOptions{
+		Altitude:         60,
+		Angle:            0,
+		Camera:           DefaultCamera(),
+		SideOverlap:      0.7,
+		FrontOverlap:     0.8,
+		GimbalPitch:      -90,
+		Crosshatch:       false,
+		CrossGimbalPitch: -90,
+		Heading:          fp2lm.HeadingStrategy{},
+	}
+}
+
+// Grid generates a back-and-forth survey covering the polygon described by area
+//
+// Parameters:
+//   - area: Vertices of the survey polygon in order (at least 3)
+//   - options: Configuration options for the survey
+//
+// Returns:
+//   - The generated missions. A single grid, or a crosshatch that fits within
+//     missioncsv.MaxLitchiWaypoints, is returned as one mission. A larger crosshatch
+//     is returned as one mission per pass, and any pass that is still too long is
+//     broken into consecutive missions.
+//
+// Each waypoint marks the end of a flight line and, by default, is headed along the line
+// toward the next waypoint. Photo distance intervals are set from the camera footprint and
+// FrontOverlap so Litchi can trigger photos along the lines.
+func Grid(area []Coordinate, options *GridOptions) ([][]*missioncsv.LitchiWaypoint, error) {
+	if options == nil {
+		options = DefaultGridOptions()
+	}
+
+	if len(area) < 3 {
+		return nil, fmt.Errorf("survey area must have at least 3 vertices, got %d", len(area))
+	}
+	if options.Altitude <= 0 {
+		return nil, fmt.Errorf("altitude must be positive, got %.1f", options.Altitude)
+	}
+	if err := options.Camera.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateOverlap("side", options.SideOverlap); err != nil {
+		return nil, err
+	}
+	if err := validateOverlap("front", options.FrontOverlap); err != nil {
+		return nil, err
+	}
+	if err := validatePitch(options.GimbalPitch); err != nil {
+		return nil, err
+	}
+	if options.Crosshatch {
+		if err := validatePitch(options.CrossGimbalPitch); err != nil {
+			return nil, err
+		}
+	}
+	if err := options.Heading.Validate(nil); err != nil {
+		return nil, err
+	}
+
+	frame := newLocalFrame(area)
+	polygon := make([][2]float64, len(area))
+	for i, c := range area {
+		polygon[i][0], polygon[i][1] = frame.toXY(c)
+	}
+
+	spacing := options.Camera.lineSpacing(options.Altitude, options.SideOverlap)
+	_, footprintHeight := options.Camera.Footprint(options.Altitude)
+	photoInterval := footprintHeight * (1 - options.FrontOverlap)
+
+	firstLines := gridLines(polygon, options.Angle, spacing)
+	if len(firstLines) == 0 {
+		return nil, fmt.Errorf("survey area is too small for a line spacing of %.1f m", spacing)
+	}
+	firstPass := serpentine(firstLines, false, false)
+
+	passes := [][][2]float64{firstPass}
+	pitches := []float64{options.GimbalPitch}
+	if options.Crosshatch {
+		crossLines := gridLines(polygon, options.Angle+90, spacing)
+		if len(crossLines) == 0 {
+			return nil, fmt.Errorf("survey area is too narrow for a crosshatch line spacing of %.1f m", spacing)
+		}
+		passes = append(passes, nearestSerpentine(crossLines, firstPass[len(firstPass)-1]))
+		pitches = append(pitches, options.CrossGimbalPitch)
+	}
+
+	var waypointPasses [][]*missioncsv.LitchiWaypoint
+	total := 0
+	for i, pass := range passes {
+		waypoints := make([]*missioncsv.LitchiWaypoint, 0, len(pass))
+		for _, p := range pass {
+			wp := newWaypoint(frame.toCoordinate(p[0], p[1]), options.Altitude, pitches[i])
+			wp.PhotoDistInterval = float32(photoInterval)
+			waypoints = append(waypoints, wp)
+		}
+		if err := options.Heading.Apply(waypoints, nil); err != nil {
+			return nil, err
+		}
+		waypointPasses = append(waypointPasses, waypoints)
+		total += len(waypoints)
+	}
+
+	if total <= missioncsv.MaxLitchiWaypoints {
+		var mission []*missioncsv.LitchiWaypoint
+		for _, waypoints := range waypointPasses {
+			mission = append(mission, waypoints...)
+		}
+		return [][]*missioncsv.LitchiWaypoint{mission}, nil
+	}
+
+	var missions [][]*missioncsv.LitchiWaypoint
+	for _, waypoints := range waypointPasses {
+		missions = append(missions, Split(waypoints)...)
+	}
+	return missions, nil
+}
+
+// validatePitch checks that a gimbal pitch is within the range supported for surveys
+func validatePitch(pitch float64) error {
+	if pitch < -90 || pitch > 0 {
+		return fmt.Errorf("gimbal pitch must be between -90 and 0 degrees, got %.1f", pitch)
+	}
+	return nil
+}
+
+// gridLines returns the flight lines covering the polygon at the given direction and
+// spacing, ordered across the area. Each line is returned as its start and end points.
+func gridLines(polygon [][2]float64, angle float64, spacing float64) [][2][2]float64 {
+	rad := angle * math.Pi / 180
+	// along points in the flight direction, across is perpendicular to it
+	along := [2]float64{math.Sin(rad), math.Cos(rad)}
+	across := [2]float64{math.Cos(rad), -math.Sin(rad)}
+
+	// Rotate the polygon so flight lines run parallel to the v axis
+	rotated := make([][2]float64, len(polygon))
+	minU, maxU := math.Inf(1), math.Inf(-1)
+	for i, p := range polygon {
+		u := p[0]*across[0] + p[1]*across[1]
+		v := p[0]*along[0] + p[1]*along[1]
+		rotated[i] = [2]float64{u, v}
+		minU = math.Min(minU, u)
+		maxU = math.Max(maxU, u)
+	}
+
+	var lines [][2][2]float64
+	for u := minU + spacing/2; u < maxU; u += spacing {
+		minV, maxV, ok := crossingExtent(rotated, u)
+		if !ok {
+			continue
+		}
+		start := [2]float64{u*across[0] + minV*along[0], u*across[1] + minV*along[1]}
+		end := [2]float64{u*across[0] + maxV*along[0], u*across[1] + maxV*along[1]}
+		lines = append(lines, [2][2]float64{start, end})
+	}
+	return lines
+}
+
+// crossingExtent returns the smallest and largest v where the vertical line at u
+// crosses the polygon boundary
+func crossingExtent(polygon [][2]float64, u float64) (minV, maxV float64, ok bool) {
+	minV, maxV = math.Inf(1), math.Inf(-1)
+	for i := range polygon {
+		a := polygon[i]
+		b := polygon[(i+1)%len(polygon)]
+		if (a[0] <= u && u < b[0]) || (b[0] <= u && u < a[0]) {
+			v := a[1] + (u-a[0])*(b[1]-a[1])/(b[0]-a[0])
+			minV = math.Min(minV, v)
+			maxV = math.Max(maxV, v)
+			ok = true
+		}
+	}
+	return minV, maxV, ok
+}
+
+// serpentine joins flight lines into a back-and-forth path. reverseOrder flies the
+// lines from last to first and flipFirst flies the first line from end to start.
+func serpentine(lines [][2][2]float64, reverseOrder bool, flipFirst bool) [][2]float64 {
+	path := make([][2]float64, 0, 2*len(lines))
+	for i := range lines {
+		line := lines[i]
+		if reverseOrder {
+			line = lines[len(lines)-1-i]
+		}
+		if (i%2 == 1) != flipFirst {
+			line[0], line[1] = line[1], line[0]
+		}
+		path = append(path, line[0], line[1])
+	}
+	return path
+}
+
+// nearestSerpentine returns the back-and-forth path over lines that starts closest to from
+func nearestSerpentine(lines [][2][2]float64, from [2]float64) [][2]float64 {
+	var best [][2]float64
+	bestDist := math.Inf(1)
+	for _, reverseOrder := range []bool{false, true} {
+		for _, flipFirst := range []bool{false, true} {
+			path := serpentine(lines, reverseOrder, flipFirst)
+			if len(path) == 0 {
+				continue
+			}
+			dist := math.Hypot(path[0][0]-from[0], path[0][1]-from[1])
+			if dist < bestDist {
+				best, bestDist = path, dist
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,174 @@
+package survey_test
+
+import (
+	"flightplan2litchimission/missioncsv"
+	"flightplan2litchimission/survey"
+	"math"
+	"testing"
+)
+
+// square returns a survey area of roughly 200x200 meters
+var square = []survey.Coordinate{
+	{Latitude: 43.0000, Longitude: -89.0000},
+	{Latitude: 43.0018, Longitude: -89.0000},
+	{Latitude: 43.0018, Longitude: -88.9975},
+	{Latitude: 43.0000, Longitude: -88.9975},
+}
+
+// TestGrid checks that a single grid produces one mission of paired line ends
+func TestGrid(t *testing.T) {
+	missions, err := survey.Grid(square, survey.DefaultGridOptions())
+	if err != nil {
+		t.Fatalf("Grid returned error: %v", err)
+	}
+	if len(missions) != 1 {
+		t.Fatalf("expected 1 mission, got %d", len(missions))
+	}
+
+	mission := missions[0]
+	if len(mission) == 0 || len(mission)%2 != 0 {
+		t.Fatalf("expected an even number of line end waypoints, got %d", len(mission))
+	}
+
+	// North-South lines alternate between heading north and south
+	for i := 0; i < len(mission)-1; i += 2 {
+		want := 0.0
+		if (i/2)%2 == 1 {
+			want = 180
+		}
+		got := float64(mission[i].Heading)
+		if math.Abs(math.Mod(got-want+540, 360)-180) > 1 {
+			t.Errorf("line %d: expected heading %.0f, got %.1f", i/2, want, got)
+		}
+		if mission[i].GimbalPitch != -90 {
+			t.Errorf("waypoint %d: expected pitch -90, got %.1f", i, mission[i].GimbalPitch)
+		}
+		if mission[i].PhotoDistInterval <= 0 {
+			t.Errorf("waypoint %d: expected a photo interval, got %.1f", i, mission[i].PhotoDistInterval)
+		}
+	}
+}
+
+// TestGridCrosshatch checks the crosshatch pass and splitting by the waypoint limit
+func TestGridCrosshatch(t *testing.T) {
+	options := survey.DefaultGridOptions()
+	options.Crosshatch = true
+	options.CrossGimbalPitch = -60
+
+	missions, err := survey.Grid(square, options)
+	if err != nil {
+		t.Fatalf("Grid returned error: %v", err)
+	}
+	if len(missions) != 1 {
+		t.Fatalf("expected 1 mission, got %d", len(missions))
+	}
+
+	oblique := 0
+	for _, wp := range missions[0] {
+		if wp.GimbalPitch == -60 {
+			oblique++
+		}
+	}
+	if oblique == 0 || oblique == len(missions[0]) {
+		t.Errorf("expected both nadir and oblique passes, got %d oblique of %d", oblique, len(missions[0]))
+	}
+
+	// A low altitude needs many more lines and forces a split per pass
+	options.Altitude = 10
+	missions, err = survey.Grid(square, options)
+	if err != nil {
+		t.Fatalf("Grid returned error: %v", err)
+	}
+	if len(missions) < 2 {
+		t.Fatalf("expected the crosshatch to be split, got %d missions", len(missions))
+	}
+	for i, mission := range missions {
+		if len(mission) > missioncsv.MaxLitchiWaypoints {
+			t.Errorf("mission %d has %d waypoints", i, len(mission))
+		}
+	}
+	if missions[0][0].GimbalPitch != -90 || missions[len(missions)-1][0].GimbalPitch != -60 {
+		t.Errorf("expected the first mission to be nadir and the last oblique")
+	}
+}
+
+// TestGridInvalid checks that bad options are rejected
+func TestGridInvalid(t *testing.T) {
+	if _, err := survey.Grid(square[:2], nil); err == nil {
+		t.Error("expected an error for a degenerate area")
+	}
+	options := survey.DefaultGridOptions()
+	options.SideOverlap = 1
+	if _, err := survey.Grid(square, options); err == nil {
+		t.Error("expected an error for 100% side overlap")
+	}
+}
+
+// assertLegsFlown checks that split missions continue from one another and together
+// fly every leg of the original route, returning the route rebuilt from the missions
+func assertLegsFlown(t *testing.T, missions [][]*missioncsv.LitchiWaypoint) []missioncsv.Point {
+	t.Helper()
+	var route []missioncsv.Point
+	for i, mission := range missions {
+		if len(mission) > missioncsv.MaxLitchiWaypoints {
+			t.Errorf("mission %d has %d waypoints", i, len(mission))
+		}
+		start := 0
+		if i > 0 {
+			if mission[0].Point != missions[i-1][len(missions[i-1])-1].Point {
+				t.Errorf("mission %d does not start where mission %d ended", i, i-1)
+			}
+			if mission[0] == missions[i-1][len(missions[i-1])-1] {
+				t.Errorf("mission %d shares its first waypoint with mission %d", i, i-1)
+			}
+			start = 1
+		}
+		for _, wp := range mission[start:] {
+			route = append(route, wp.Point)
+		}
+	}
+	return route
+}
+
+// TestGridSplitFliesEveryLine checks that no flight line is lost where a large grid is
+// split into several missions
+func TestGridSplitFliesEveryLine(t *testing.T) {
+	// About 800 m wide at a low altitude needs well over 99 line ends
+	area := []survey.Coordinate{
+		{Latitude: 43.000, Longitude: -89.000},
+		{Latitude: 43.002, Longitude: -89.000},
+		{Latitude: 43.002, Longitude: -88.990},
+		{Latitude: 43.000, Longitude: -88.990},
+	}
+	options := survey.DefaultGridOptions()
+	options.Altitude = 10
+
+	missions, err := survey.Grid(area, options)
+	if err != nil {
+		t.Fatalf("Grid returned error: %v", err)
+	}
+	if len(missions) < 2 {
+		t.Fatalf("expected the grid to be split, got %d missions", len(missions))
+	}
+
+	// Every line runs from one waypoint to the next, so every line end is flown to
+	// from its start within a single mission
+	route := assertLegsFlown(t, missions)
+	if len(route)%2 != 0 {
+		t.Fatalf("expected paired line ends, got %d waypoints", len(route))
+	}
+	for line := 0; line < len(route)/2; line++ {
+		start, end := route[2*line], route[2*line+1]
+		flown := false
+		for _, mission := range missions {
+			for i := 0; i+1 < len(mission); i++ {
+				if mission[i].Point == start && mission[i+1].Point == end {
+					flown = true
+				}
+			}
+		}
+		if !flown {
+			t.Errorf("line %d is not flown in any mission", line+1)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Package survey generates Litchi waypoint patterns for mapping and inspection flights.
+//
+// The generators in this package work on a local east/north plane (fp2lm.LocalPlane)
+// centred on the survey area, which keeps the geometry simple while staying well
+// within GPS accuracy for areas a few kilometers across. Headings between waypoints
+// are computed with fp2lm.CalculateBearing and fp2lm.HeadingStrategy so generated
+// missions behave like converted ones.
+package survey
+
+import (
+	"flightplan2litchimission/fp2lm"
+	"flightplan2litchimission/missioncsv"
+	"fmt"
+)
+
+// Coordinate is a geographic position in decimal degrees
+type Coordinate struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Camera describes the imaging geometry used to space flight lines and photos
+type Camera struct {
+	// SensorWidth is the sensor dimension across the flight line in millimeters
+	SensorWidth float64
+	// SensorHeight is the sensor dimension along the flight line in millimeters
+	SensorHeight float64
+	// FocalLength is the lens focal length in millimeters
+	FocalLength float64
+}
+
+// DefaultCamera returns the geometry of a typical 1" sensor drone camera
+// (13.2 x 8.8 mm sensor behind an 8.8 mm lens)
+func DefaultCamera() Camera {
+	return Camera{
+		SensorWidth:  13.2,
+		SensorHeight: 8.8,
+		FocalLength:  8.8,
+	}
+}
+
+// Footprint returns the ground width (across track) and height (along track) in meters
+// covered by a nadir image taken at the given distance from the surface
+func (c Camera) Footprint(distance float64) (width, height float64) {
+	return distance * c.SensorWidth / c.FocalLength, distance * c.SensorHeight / c.FocalLength
+}
+
+// validate checks that the camera geometry is usable
+func (c Camera) validate() error {
+	if c.SensorWidth <= 0 || c.SensorHeight <= 0 || c.FocalLength <= 0 {
+		return fmt.Errorf("camera sensor size and focal length must be positive, got %.1fx%.1f mm at %.1f mm",
+			c.SensorWidth, c.SensorHeight, c.FocalLength)
+	}
+	return nil
+}
+
+// lineSpacing returns the distance between adjacent flight lines that gives the
+// requested side overlap at the given distance from the surface
+func (c Camera) lineSpacing(distance, sideOverlap float64) float64 {
+	width, _ := c.Footprint(distance)
+	return width * (1 - sideOverlap)
+}
+
+// validateOverlap checks that an overlap fraction is in the range [0, 1)
+func validateOverlap(name string, overlap float64) error {
+	if overlap < 0 || overlap >= 1 {
+		return fmt.Errorf("%s overlap must be between 0 and 1 (exclusive), got %.2f", name, overlap)
+	}
+	return nil
+}
+
+// localFrame adapts fp2lm.LocalPlane to Coordinate values
+type localFrame struct {
+	fp2lm.LocalPlane
+}
+
+// newLocalFrame creates a local frame centred on the average of the given coordinates
+func newLocalFrame(coords []Coordinate) localFrame {
+	var lat, lon float64
+	for _, c := range coords {
+		lat += c.Latitude
+		lon += c.Longitude
+	}
+	lat /= float64(len(coords))
+	lon /= float64(len(coords))
+	return localFrame{fp2lm.NewLocalPlane(lat, lon)}
+}
+
+// toXY converts a coordinate to east (x) and north (y) offsets from the origin in meters
+func (f localFrame) toXY(c Coordinate) (x, y float64) {
+	return f.ToXY(c.Latitude, c.Longitude)
+}
+
+// toCoordinate converts east (x) and north (y) offsets from the origin back to a coordinate
+func (f localFrame) toCoordinate(x, y float64) Coordinate {
+	lat, lon := f.ToLatLon(x, y)
+	return Coordinate{Latitude: lat, Longitude: lon}
+}
+
+// newWaypoint creates a relative-altitude waypoint at the given position
+func newWaypoint(c Coordinate, altitude float64, gimbalPitch float64) *missioncsv.LitchiWaypoint {
+	wp := missioncsv.NewLitchiWaypoint()
+	wp.Point.Latitude = c.Latitude
+	wp.Point.Longitude = c.Longitude
+	wp.Point.Altitude = altitude
+	wp.GimbalPitch = float32(gimbalPitch)
+	wp.AltitudeMode = 1 // Relative (AGL)
+	return wp
+}
+
+// Split breaks a waypoint list into consecutive missions of at most
+// missioncsv.MaxLitchiWaypoints waypoints each
+//
+// Each mission after the first starts with a copy of the waypoint that ended the
+// previous one, so the leg across every split is still flown and no flight line is
+// lost between missions.
+func Split(waypoints []*missioncsv.LitchiWaypoint) [][]*missioncsv.LitchiWaypoint {
+	var missions [][]*missioncsv.LitchiWaypoint
+	for start := 0; start < len(waypoints); start += missioncsv.MaxLitchiWaypoints - 1 {
+		end := start + missioncsv.MaxLitchiWaypoints
+		if end > len(waypoints) {
+			end = len(waypoints)
+		}
+		mission := make([]*missioncsv.LitchiWaypoint, end-start)
+		copy(mission, waypoints[start:end])
+		if start > 0 {
+			// The previous mission ended here, so give this one its own copy
+			first := *mission[0]
+			mission[0] = &first
+		}
+		missions = append(missions, mission)
+		if end == len(waypoints) {
+			break
+		}
+	}
+	return missions
+}